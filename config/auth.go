@@ -0,0 +1,49 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// minSecretLength is the shortest JWT_SECRET accepted, the size of the
+// HMAC-SHA256 key tokens are signed with.
+const minSecretLength = 32
+
+// AuthConfig holds the JWT signing settings and the optional first user that
+// is created when the users table is empty.
+type AuthConfig struct {
+	Secret        []byte
+	Issuer        string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	AdminEmail    string
+	AdminPassword string
+}
+
+func NewAuthConfig() AuthConfig {
+	cfg := AuthConfig{
+		Secret:        []byte(os.Getenv("JWT_SECRET")),
+		Issuer:        getEnvDefault("JWT_ISSUER", "test-backend-altech"),
+		AccessTTL:     15 * time.Minute,
+		RefreshTTL:    7 * 24 * time.Hour,
+		AdminEmail:    os.Getenv("AUTH_ADMIN_EMAIL"),
+		AdminPassword: os.Getenv("AUTH_ADMIN_PASSWORD"),
+	}
+	if v, err := strconv.Atoi(os.Getenv("JWT_ACCESS_TTL_MINUTES")); err == nil && v > 0 {
+		cfg.AccessTTL = time.Duration(v) * time.Minute
+	}
+	if v, err := strconv.Atoi(os.Getenv("JWT_REFRESH_TTL_HOURS")); err == nil && v > 0 {
+		cfg.RefreshTTL = time.Duration(v) * time.Hour
+	}
+
+	// A random key would log everyone out on restart and differ between
+	// instances, a short one can be guessed, so neither is accepted.
+	if len(cfg.Secret) < minSecretLength {
+		log.Fatalf("JWT_SECRET must be set to at least %d bytes", minSecretLength)
+	}
+	return cfg
+}
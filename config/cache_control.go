@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// CacheControlConfig holds the Cache-Control header sent by each cacheable
+// route. The default "no-cache" lets clients keep responses but makes them
+// revalidate with If-None-Match, which is answered with 304 when unchanged.
+type CacheControlConfig struct {
+	AuthorsList   string
+	AuthorsDetail string
+	BooksList     string
+	BooksDetail   string
+}
+
+func NewCacheControlConfig() CacheControlConfig {
+	return CacheControlConfig{
+		AuthorsList:   getEnvDefault("CACHE_CONTROL_AUTHORS_LIST", "no-cache"),
+		AuthorsDetail: getEnvDefault("CACHE_CONTROL_AUTHORS_DETAIL", "no-cache"),
+		BooksList:     getEnvDefault("CACHE_CONTROL_BOOKS_LIST", "no-cache"),
+		BooksDetail:   getEnvDefault("CACHE_CONTROL_BOOKS_DETAIL", "no-cache"),
+	}
+}
+
+func getEnvDefault(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
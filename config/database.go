@@ -29,6 +29,32 @@ var (
 	TimeOutDuration, _ = strconv.Atoi(os.Getenv("DB_CONNECTION_TIMEOUT"))
 )
 
+// TxRetryConfig controls how transactions that fail with a serialization
+// failure or a deadlock are retried.
+type TxRetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func NewTxRetryConfig() TxRetryConfig {
+	cfg := TxRetryConfig{
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    500 * time.Millisecond,
+	}
+	if v, err := strconv.Atoi(os.Getenv("DB_TX_MAX_ATTEMPTS")); err == nil && v > 0 {
+		cfg.MaxAttempts = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("DB_TX_RETRY_BASE_DELAY_MS")); err == nil && v >= 0 {
+		cfg.BaseDelay = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("DB_TX_RETRY_MAX_DELAY_MS")); err == nil && v >= 0 {
+		cfg.MaxDelay = time.Duration(v) * time.Millisecond
+	}
+	return cfg
+}
+
 func NewPostgresDatabase() *pgxpool.Pool {
 	logger := utils.NewLogger()
 
@@ -69,6 +95,10 @@ func NewPostgresDatabase() *pgxpool.Pool {
 		logger.Error("Failed to execute SQL files", "error", err)
 		return nil
 	}
+	if err := backfillNormalizedNames(pool, logger); err != nil {
+		logger.Error("Failed to fill in normalized names", "error", err)
+		return nil
+	}
 	return pool
 }
 
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+type IdempotencyConfig struct {
+	// TTL is how long the response of a key is replayed.
+	TTL time.Duration
+	// InFlightTTL is how long a key stays taken by a request that has not
+	// answered yet. Requests are not cut off, so it has to be longer than
+	// the slowest request; a request still running after it can run twice.
+	// The marker of a crashed server also only expires after it.
+	InFlightTTL time.Duration
+}
+
+func NewIdempotencyConfig() IdempotencyConfig {
+	cfg := IdempotencyConfig{
+		TTL:         24 * time.Hour,
+		InFlightTTL: 5 * time.Minute,
+	}
+	if v, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS")); err == nil && v > 0 {
+		cfg.TTL = time.Duration(v) * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_IN_FLIGHT_SECONDS")); err == nil && v > 0 {
+		cfg.InFlightTTL = time.Duration(v) * time.Second
+	}
+	return cfg
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// normalizedColumn is a column holding domain.NormalizeName of another column
+// of the same table, unique among active rows that share scope.
+type normalizedColumn struct {
+	table  string
+	source string
+	target string
+	scope  string // Column the uniqueness is per, empty for the whole table
+}
+
+var normalizedColumns = []normalizedColumn{
+	{table: "authors", source: "name", target: "name_normalized"},
+	{table: "books", source: "title", target: "title_normalized", scope: "author_id"},
+}
+
+// backfillNormalizedNames fills the normalized columns of rows written before
+// the columns existed and then makes them NOT NULL. PostgreSQL has no full
+// Unicode case folding, so this can not be done by the migration itself. When
+// active rows collide once normalized it fails and names them; they have to
+// be merged or deleted before the API can start.
+func backfillNormalizedNames(pool *pgxpool.Pool, logger *zap.SugaredLogger) error {
+	for _, column := range normalizedColumns {
+		if err := pgx.BeginFunc(context.Background(), pool, func(tx pgx.Tx) error {
+			return backfillNormalizedColumn(context.Background(), tx, column, logger)
+		}); err != nil {
+			return fmt.Errorf("backfill %s.%s: %w", column.table, column.target, err)
+		}
+	}
+	return nil
+}
+
+func backfillNormalizedColumn(c context.Context, tx pgx.Tx, column normalizedColumn, logger *zap.SugaredLogger) error {
+	var missing int
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s IS NULL", column.table, column.target)
+	if err := tx.QueryRow(c, query).Scan(&missing); err != nil {
+		return err
+	}
+
+	if missing > 0 {
+		scope := "''"
+		if column.scope != "" {
+			scope = fmt.Sprintf("COALESCE(%s::text, '')", column.scope)
+		}
+		query = fmt.Sprintf("SELECT id::text, %s, %s, %s IS NULL, deleted_at IS NULL FROM %s",
+			column.source, scope, column.target, column.table)
+		rows, err := tx.Query(c, query)
+		if err != nil {
+			return err
+		}
+
+		var ids, values []string
+		groups := make(map[string][]string)
+		names := make(map[string]string)
+		for rows.Next() {
+			var id, value, scopeValue string
+			var isMissing, active bool
+			if err := rows.Scan(&id, &value, &scopeValue, &isMissing, &active); err != nil {
+				rows.Close()
+				return err
+			}
+
+			normalized := domain.NormalizeName(value)
+			if isMissing {
+				ids = append(ids, id)
+				values = append(values, normalized)
+			}
+			// Rows without an author are never unique against each other.
+			if active && (column.scope == "" || scopeValue != "") {
+				key := scopeValue + "\x00" + normalized
+				groups[key] = append(groups[key], id)
+				if _, ok := names[key]; !ok {
+					names[key] = value
+				}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		var duplicates []string
+		for key, group := range groups {
+			if len(group) > 1 {
+				sort.Strings(group)
+				duplicates = append(duplicates, fmt.Sprintf("%q (%s)", names[key], strings.Join(group, ", ")))
+			}
+		}
+		if len(duplicates) > 0 {
+			sort.Strings(duplicates)
+			return fmt.Errorf("merge or delete the duplicate %s first: %s", column.table, strings.Join(duplicates, "; "))
+		}
+
+		// Filling in the column is no change of the record, so the triggers
+		// that bump updated_at and record history stay off meanwhile.
+		if _, err := tx.Exec(c, fmt.Sprintf("ALTER TABLE %s DISABLE TRIGGER USER", column.table)); err != nil {
+			return err
+		}
+		query = fmt.Sprintf(`UPDATE %s AS t SET %s = v.normalized
+			FROM unnest($1::text[], $2::text[]) AS v(id, normalized)
+			WHERE t.id = v.id::uuid`, column.table, column.target)
+		if _, err := tx.Exec(c, query, ids, values); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(c, fmt.Sprintf("ALTER TABLE %s ENABLE TRIGGER USER", column.table)); err != nil {
+			return err
+		}
+		logger.Infow("Normalized column filled in", "table", column.table, "column", column.target, "rows", len(ids))
+	}
+
+	_, err := tx.Exec(c, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", column.table, column.target))
+	return err
+}
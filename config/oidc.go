@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// OIDCConfig configures login through an external OpenID Connect identity
+// provider. OIDC is disabled while Issuer is empty.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Audience is required in access tokens the provider issued for this
+	// API. It defaults to the client id.
+	Audience string
+	Scopes   []string
+	// GroupsClaim names the claim holding the groups of the user.
+	GroupsClaim string
+	// GroupRoles maps provider groups to roles of this service. Users in no
+	// mapped group get DefaultRole.
+	GroupRoles  map[string][]string
+	DefaultRole string
+	// LinkLocalUsers lets a first OIDC login take over the local account
+	// with the same email. Off by default, such accounts keep their roles.
+	LinkLocalUsers bool
+	// JWKSCacheTTL is how long discovery and signing keys are kept in the
+	// cache before they are fetched again.
+	JWKSCacheTTL time.Duration
+}
+
+func NewOIDCConfig() OIDCConfig {
+	cfg := OIDCConfig{
+		Issuer:       strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Audience:     os.Getenv("OIDC_AUDIENCE"),
+		Scopes:       strings.Fields(getEnvDefault("OIDC_SCOPES", "openid email profile")),
+		GroupsClaim:  getEnvDefault("OIDC_GROUPS_CLAIM", "groups"),
+		GroupRoles:   parseGroupRoles(os.Getenv("OIDC_GROUP_ROLES")),
+		DefaultRole:  getEnvDefault("OIDC_DEFAULT_ROLE", "reader"),
+		JWKSCacheTTL: time.Hour,
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = cfg.ClientID
+	}
+	if v, err := strconv.ParseBool(os.Getenv("OIDC_LINK_LOCAL_USERS")); err == nil {
+		cfg.LinkLocalUsers = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OIDC_JWKS_CACHE_MINUTES")); err == nil && v > 0 {
+		cfg.JWKSCacheTTL = time.Duration(v) * time.Minute
+	}
+	return cfg
+}
+
+func (cfg OIDCConfig) Enabled() bool {
+	return cfg.Issuer != ""
+}
+
+// parseGroupRoles reads "group=role,group=role". A group listed twice gets
+// every role it is listed with.
+func parseGroupRoles(value string) map[string][]string {
+	groupRoles := map[string][]string{}
+	for _, pair := range strings.Split(value, ",") {
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || role == "" {
+			continue
+		}
+		groupRoles[group] = append(groupRoles[group], role)
+	}
+	return groupRoles
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// Sinks the outbox relay can publish to.
+const (
+	OutboxPublisherStdout = "stdout"
+	OutboxPublisherFile   = "file"
+	OutboxPublisherRedis  = "redis"
+)
+
+// OutboxConfig controls the relay that publishes the domain events written
+// to the outbox table.
+type OutboxConfig struct {
+	// Publisher is one of stdout, file or redis.
+	Publisher string
+	// FilePath is the file events are appended to, one JSON object per line.
+	FilePath string
+	// RedisStream is the stream events are added to, trimmed to about
+	// RedisMaxLen entries.
+	RedisStream string
+	RedisMaxLen int64
+	Interval    time.Duration
+	BatchSize   int
+	// MaxAttempts is how often an event is tried before it is dead lettered
+	// and the later events of its entity are published without it.
+	MaxAttempts int
+	// Retention is how long published events stay in the outbox table.
+	Retention time.Duration
+}
+
+func NewOutboxConfig() OutboxConfig {
+	cfg := OutboxConfig{
+		Publisher:   strings.ToLower(getEnvDefault("OUTBOX_PUBLISHER", OutboxPublisherStdout)),
+		FilePath:    getEnvDefault("OUTBOX_FILE_PATH", "events.jsonl"),
+		RedisStream: getEnvDefault("OUTBOX_REDIS_STREAM", "catalog:events"),
+		RedisMaxLen: 100000,
+		Interval:    time.Second,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		Retention:   7 * 24 * time.Hour,
+	}
+	if v, err := strconv.ParseInt(os.Getenv("OUTBOX_REDIS_MAXLEN"), 10, 64); err == nil && v > 0 {
+		cfg.RedisMaxLen = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_RELAY_INTERVAL_MS")); err == nil && v > 0 {
+		cfg.Interval = time.Duration(v) * time.Millisecond
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && v > 0 {
+		cfg.MaxAttempts = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OUTBOX_RETENTION_HOURS")); err == nil && v >= 0 {
+		cfg.Retention = time.Duration(v) * time.Hour
+	}
+	return cfg
+}
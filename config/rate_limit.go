@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"test-backend-altech/utils"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// Names of the rate limit policies.
+const (
+	RateLimitDefault = "default"
+	RateLimitList    = "list"
+	RateLimitAuth    = "auth"
+)
+
+// RateLimitPolicy allows Limit requests per Window with bursts of up to
+// Limit requests.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	// ByIP keys the policy by client IP even for authenticated callers.
+	ByIP bool
+}
+
+// RateLimitRoute applies a policy to requests with Method whose path is Path,
+// or starts with Path when it ends in "/*".
+type RateLimitRoute struct {
+	Method string
+	Path   string
+	Policy string
+}
+
+type RateLimitConfig struct {
+	Enabled  bool
+	Policies map[string]RateLimitPolicy
+	// Routes are matched in order, requests matching none use the default
+	// policy.
+	Routes []RateLimitRoute
+}
+
+func NewRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled: os.Getenv("RATE_LIMIT_ENABLED") != "false",
+		Policies: map[string]RateLimitPolicy{
+			RateLimitDefault: parseRateLimitPolicy(RateLimitDefault, "RATE_LIMIT_DEFAULT", "600/1m", false),
+			RateLimitList:    parseRateLimitPolicy(RateLimitList, "RATE_LIMIT_LIST", "120/1m", false),
+			RateLimitAuth:    parseRateLimitPolicy(RateLimitAuth, "RATE_LIMIT_AUTH", "10/1m", true),
+		},
+		Routes: []RateLimitRoute{
+			{Method: "GET", Path: "/books", Policy: RateLimitList},
+			{Method: "GET", Path: "/authors", Policy: RateLimitList},
+			{Method: "GET", Path: "/trash", Policy: RateLimitList},
+			{Method: "POST", Path: "/auth/login", Policy: RateLimitAuth},
+			{Method: "POST", Path: "/auth/refresh", Policy: RateLimitAuth},
+			{Method: "GET", Path: "/auth/oidc/*", Policy: RateLimitAuth},
+		},
+	}
+}
+
+// Policy returns the policy of a request.
+func (cfg RateLimitConfig) Policy(method string, path string) RateLimitPolicy {
+	path = strings.TrimSuffix(path, "/")
+	for _, route := range cfg.Routes {
+		if route.Method != method {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(route.Path, "/*"); ok && strings.HasPrefix(path, prefix+"/") || route.Path == path {
+			return cfg.Policies[route.Policy]
+		}
+	}
+	return cfg.Policies[RateLimitDefault]
+}
+
+// parseRateLimitPolicy reads a policy written as "<limit>/<window>", such as
+// "120/1m". An invalid value is logged and replaced by fallback.
+func parseRateLimitPolicy(name string, key string, fallback string, byIP bool) RateLimitPolicy {
+	value := getEnvDefault(key, fallback)
+	limit, window, ok := parseRate(value)
+	if !ok {
+		utils.NewLogger().Warnf("%s=%q is not <limit>/<window>, using %s", key, value, fallback)
+		limit, window, _ = parseRate(fallback)
+	}
+	return RateLimitPolicy{Name: name, Limit: limit, Window: window, ByIP: byIP}
+}
+
+func parseRate(value string) (int, time.Duration, bool) {
+	limit, window, ok := strings.Cut(value, "/")
+	n, errLimit := strconv.Atoi(limit)
+	d, errWindow := time.ParseDuration(window)
+	if !ok || errLimit != nil || errWindow != nil || n <= 0 || d <= 0 {
+		return 0, 0, false
+	}
+	return n, d, true
+}
@@ -8,11 +8,22 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrCacheMiss is returned by Get and GetDel when the key does not exist.
+var ErrCacheMiss = redis.Nil
+
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
+	GetDel(ctx context.Context, key string) ([]byte, error)
 	Set(ctx context.Context, key string, value []byte, expiration time.Duration) error
+	// SetNX sets key only if it does not exist and reports whether it did.
+	SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error)
 	Delete(ctx context.Context, key string) error
 	DeletePattern(ctx context.Context, pattern string) error
+	// Eval runs a Lua script atomically on the server.
+	Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
+	// XAdd appends an entry to stream, trimming it to about maxLen entries,
+	// and returns the id of the entry.
+	XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error)
 }
 type RedisCache struct {
 	client *redis.Client
@@ -54,14 +65,39 @@ func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return r.client.Get(ctx, key).Bytes()
 }
 
+// GetDel returns the value of key and deletes it in one step, so only one
+// caller can ever consume a key.
+func (r *RedisCache) GetDel(ctx context.Context, key string) ([]byte, error) {
+	return r.client.GetDel(ctx, key).Bytes()
+}
+
 func (r *RedisCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
 	return r.client.Set(ctx, key, value, expiration).Err()
 }
 
+func (r *RedisCache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// Eval runs script by its SHA and only sends the source when the server does
+// not know it yet.
+func (r *RedisCache) Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(ctx, r.client, keys, args...).Result()
+}
+
+func (r *RedisCache) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: values,
+	}).Result()
+}
+
 func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
 	iter := r.client.Scan(ctx, 0, pattern, 0).Iterator()
 	for iter.Next(ctx) {
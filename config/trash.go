@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// TrashConfig controls how long soft-deleted authors and books are kept
+// before the purge job removes them for good.
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+func NewTrashConfig() TrashConfig {
+	cfg := TrashConfig{
+		Retention:     30 * 24 * time.Hour,
+		PurgeInterval: time.Hour,
+	}
+	if v, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_HOURS")); err == nil && v >= 0 {
+		cfg.Retention = time.Duration(v) * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("TRASH_PURGE_INTERVAL_MINUTES")); err == nil && v > 0 {
+		cfg.PurgeInterval = time.Duration(v) * time.Minute
+	}
+	return cfg
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"test-backend-altech/model/civil"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator that reports fields by their JSON name.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// Validate dates as their ISO string so "required" treats the zero Date as empty.
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		date, ok := field.Interface().(civil.Date)
+		if !ok || date.IsZero() {
+			return nil
+		}
+		return date.String()
+	}, civil.Date{})
+	return validate
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	req "test-backend-altech/model/web/req"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminController interface {
+	Route(app *fiber.App)
+}
+
+type adminController struct {
+	validate    *validator.Validate
+	roleService service.RoleService
+}
+
+func NewAdminController(validate *validator.Validate, roleService service.RoleService) AdminController {
+	return &adminController{
+		validate:    validate,
+		roleService: roleService,
+	}
+}
+
+func (controller *adminController) Route(app *fiber.App) {
+	api := app.Group("/admin")
+
+	roles := api.Group("/roles", middleware.RequirePermission(domain.PermissionRolesManage))
+	roles.Get("/",
+		controller.FindAllRoles,
+	)
+	roles.Post("/",
+		controller.CreateRole,
+	)
+	roles.Put("/:role/permissions",
+		controller.SetRolePermissions,
+	)
+	roles.Delete("/:role",
+		controller.DeleteRole,
+	)
+	api.Get("/permissions",
+		middleware.RequirePermission(domain.PermissionRolesManage),
+		controller.FindAllPermissions,
+	)
+
+	users := api.Group("/users", middleware.RequirePermission(domain.PermissionUsersManage))
+	users.Get("/:user_id/roles",
+		controller.FindUserRoles,
+	)
+	users.Put("/:user_id/roles",
+		controller.SetUserRoles,
+	)
+}
+
+func (controller *adminController) FindAllRoles(ctx *fiber.Ctx) error {
+	roles, err := controller.roleService.FindAllRoles(ctx.Context())
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, roles)
+}
+
+func (controller *adminController) CreateRole(ctx *fiber.Ctx) error {
+	var request req.RoleRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	role, err := controller.roleService.CreateRole(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, role)
+}
+
+func (controller *adminController) SetRolePermissions(ctx *fiber.Ctx) error {
+	var request req.RolePermissionsRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	role, err := controller.roleService.SetRolePermissions(ctx.Context(), ctx.Params("role"), request)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, role)
+}
+
+func (controller *adminController) DeleteRole(ctx *fiber.Ctx) error {
+	if err := controller.roleService.DeleteRole(ctx.Context(), ctx.Params("role")); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+	})
+}
+
+func (controller *adminController) FindAllPermissions(ctx *fiber.Ctx) error {
+	permissions, err := controller.roleService.FindAllPermissions(ctx.Context())
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, permissions)
+}
+
+func (controller *adminController) FindUserRoles(ctx *fiber.Ctx) error {
+	userId := ctx.Params("user_id")
+	if err := validateID(userId, "user_id"); err != nil {
+		return err
+	}
+
+	roles, err := controller.roleService.FindUserRoles(ctx.Context(), userId)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, roles)
+}
+
+func (controller *adminController) SetUserRoles(ctx *fiber.Ctx) error {
+	userId := ctx.Params("user_id")
+	if err := validateID(userId, "user_id"); err != nil {
+		return err
+	}
+
+	var request req.UserRolesRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	roles, err := controller.roleService.SetUserRoles(ctx.Context(), userId, request)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, roles)
+}
+
+func sendData(ctx *fiber.Ctx, data interface{}) error {
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
+	})
+}
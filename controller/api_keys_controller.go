@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	req "test-backend-altech/model/web/req"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApiKeyController interface {
+	Route(app *fiber.App)
+}
+
+type apiKeyController struct {
+	validate      *validator.Validate
+	apiKeyService service.ApiKeyService
+}
+
+func NewApiKeyController(validate *validator.Validate, apiKeyService service.ApiKeyService) ApiKeyController {
+	return &apiKeyController{
+		validate:      validate,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Route registers the endpoints a user manages its own keys with. No scope
+// grants apikeys.manage, so an API key can not create or revoke keys.
+func (controller *apiKeyController) Route(app *fiber.App) {
+	api := app.Group("/api-keys", middleware.RequirePermission(domain.PermissionApiKeysManage))
+	api.Get("/",
+		controller.FindApiKeys,
+	)
+	api.Post("/",
+		controller.CreateApiKey,
+	)
+	api.Delete("/:key_id",
+		controller.RevokeApiKey,
+	)
+}
+
+func (controller *apiKeyController) FindApiKeys(ctx *fiber.Ctx) error {
+	principal, _ := ctx.Locals(domain.PrincipalKey).(domain.Principal)
+	keys, err := controller.apiKeyService.FindApiKeys(ctx.Context(), principal.UserId)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, keys)
+}
+
+func (controller *apiKeyController) CreateApiKey(ctx *fiber.Ctx) error {
+	var request req.ApiKeyRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	principal, _ := ctx.Locals(domain.PrincipalKey).(domain.Principal)
+	key, err := controller.apiKeyService.CreateApiKey(ctx.Context(), principal.UserId, request)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    key,
+	})
+}
+
+func (controller *apiKeyController) RevokeApiKey(ctx *fiber.Ctx) error {
+	keyId := ctx.Params("key_id")
+	if err := validateID(keyId, "key_id"); err != nil {
+		return err
+	}
+
+	principal, _ := ctx.Locals(domain.PrincipalKey).(domain.Principal)
+	key, err := controller.apiKeyService.RevokeApiKey(ctx.Context(), principal.UserId, keyId)
+	if err != nil {
+		return err
+	}
+	return sendData(ctx, key)
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	req "test-backend-altech/model/web/req"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController interface {
+	Route(app *fiber.App)
+}
+
+type auditController struct {
+	validate     *validator.Validate
+	auditService service.AuditService
+}
+
+func NewAuditController(validate *validator.Validate, auditService service.AuditService) AuditController {
+	return &auditController{
+		validate:     validate,
+		auditService: auditService,
+	}
+}
+
+func (controller *auditController) Route(app *fiber.App) {
+	app.Get("/audit",
+		middleware.RequirePermission(domain.PermissionAuditRead),
+		controller.FindAuditEntries,
+	)
+}
+
+func (controller *auditController) FindAuditEntries(ctx *fiber.Ctx) error {
+	var request req.AuditRequest
+	if err := ctx.QueryParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	from, err := parseTimeQuery(ctx, "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseTimeQuery(ctx, "to")
+	if err != nil {
+		return err
+	}
+
+	entries, total, err := controller.auditService.FindAuditEntries(ctx.Context(), request, from, to)
+	if err != nil {
+		return err
+	}
+
+	page, perPage := request.Page, request.PerPage
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = service.DefaultAuditPerPage
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponsePagination{
+		Code:      fiber.StatusOK,
+		Status:    true,
+		Page:      page,
+		TotalPage: (total + perPage - 1) / perPage,
+		TotalData: total,
+		Message:   "success",
+		Data:      entries,
+	})
+}
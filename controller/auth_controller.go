@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	req "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthController interface {
+	Route(app *fiber.App)
+}
+
+type authController struct {
+	validate    *validator.Validate
+	authService service.AuthService
+	userService service.UserService
+}
+
+func NewAuthController(validate *validator.Validate, authService service.AuthService, userService service.UserService) AuthController {
+	return &authController{
+		validate:    validate,
+		authService: authService,
+		userService: userService,
+	}
+}
+
+func (controller *authController) Route(app *fiber.App) {
+	api := app.Group("/auth")
+	api.Post("/login",
+		controller.Login,
+	)
+	api.Post("/refresh",
+		controller.Refresh,
+	)
+	api.Post("/logout",
+		controller.Logout,
+	)
+	api.Get("/me",
+		middleware.RequireAuth(),
+		controller.Me,
+	)
+}
+
+func (controller *authController) Login(ctx *fiber.Ctx) error {
+	var request req.LoginRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	tokens, err := controller.authService.Login(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+	return sendTokens(ctx, tokens)
+}
+
+func (controller *authController) Refresh(ctx *fiber.Ctx) error {
+	var request req.RefreshRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	tokens, err := controller.authService.Refresh(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+	return sendTokens(ctx, tokens)
+}
+
+func (controller *authController) Logout(ctx *fiber.Ctx) error {
+	var request req.RefreshRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	if err := controller.authService.Logout(ctx.Context(), request); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+	})
+}
+
+func (controller *authController) Me(ctx *fiber.Ctx) error {
+	principal, _ := ctx.Locals(domain.PrincipalKey).(domain.Principal)
+	user, err := controller.userService.FindByID(ctx.Context(), principal.UserId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    user,
+	})
+}
+
+// sendTokens answers with a token pair. Tokens must never be cached.
+func sendTokens(ctx *fiber.Ctx, tokens response.TokenResponse) error {
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    tokens,
+	})
+}
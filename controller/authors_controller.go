@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"time"
+
+	"test-backend-altech/config"
 	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
 	web "test-backend-altech/model/web"
 	req "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
 	"test-backend-altech/service"
 
 	"github.com/go-playground/validator/v10"
@@ -17,48 +23,83 @@ type AuthorController interface {
 type authorController struct {
 	validate      *validator.Validate
 	authorService service.AuthorService
+	cache         config.Cache
+	cacheControl  config.CacheControlConfig
+	idempotency   config.IdempotencyConfig
 }
 
-func NewAuthorController(validate *validator.Validate, authorService service.AuthorService) AuthorController {
+func NewAuthorController(validate *validator.Validate, authorService service.AuthorService, cache config.Cache, cacheControl config.CacheControlConfig, idempotency config.IdempotencyConfig) AuthorController {
 	return &authorController{
 		validate:      validate,
 		authorService: authorService,
+		cache:         cache,
+		cacheControl:  cacheControl,
+		idempotency:   idempotency,
 	}
 }
 func (controller *authorController) Route(app *fiber.App) {
 	api := app.Group("/authors")
 
 	api.Post("/",
+		middleware.RequirePermission(domain.PermissionAuthorsCreate),
+		middleware.Idempotency(controller.cache, controller.idempotency),
 		controller.CreateAuthor,
 	)
 	api.Get("/:author_id",
+		middleware.CacheControl(controller.cacheControl.AuthorsDetail),
 		controller.FindByID,
 	)
 	api.Put("/:author_id",
+		middleware.RequirePermission(domain.PermissionAuthorsUpdate),
 		controller.UpdateAuthor)
+	api.Patch("/:author_id",
+		middleware.RequirePermission(domain.PermissionAuthorsUpdate),
+		controller.PatchAuthor)
 
 	api.Get("/",
+		middleware.CacheControl(controller.cacheControl.AuthorsList),
 		controller.FindAllAuthor,
 	)
 
 	api.Delete("/:author_id",
+		middleware.RequirePermission(domain.PermissionAuthorsDelete),
 		controller.DeleteAuthor,
 	)
+	api.Post("/:author_id/restore",
+		middleware.RequirePermission(domain.PermissionAuthorsDelete),
+		controller.RestoreAuthor,
+	)
+	api.Get("/:author_id/versions",
+		middleware.RequirePermission(domain.PermissionHistoryRead),
+		controller.FindAuthorVersions,
+	)
+	api.Get("/:author_id/diff",
+		middleware.RequirePermission(domain.PermissionHistoryRead),
+		controller.DiffAuthorVersions,
+	)
+	api.Post("/:author_id/revert",
+		middleware.RequirePermission(domain.PermissionAuthorsUpdate),
+		controller.RevertAuthor,
+	)
+	api.Post("/:author_id/merge",
+		middleware.RequirePermission(domain.PermissionAuthorsMerge),
+		controller.MergeAuthors,
+	)
 }
 func (controller *authorController) CreateAuthor(ctx *fiber.Ctx) error {
 	var request req.AuthorRequest
 	err := ctx.BodyParser(&request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 
 	err = controller.validate.Struct(request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 	authorResponse, err := controller.authorService.CreateAuthor(ctx.Context(), request)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
@@ -72,16 +113,26 @@ func (controller *authorController) CreateAuthor(ctx *fiber.Ctx) error {
 func (controller *authorController) FindByID(ctx *fiber.Ctx) error {
 
 	authorId := ctx.Params("author_id")
+	if err := validateID(authorId, "author_id"); err != nil {
+		return err
+	}
+	asOf, err := parseTimeQuery(ctx, "as_of")
+	if err != nil {
+		return err
+	}
+	if !asOf.IsZero() {
+		return controller.FindAuthorAsOf(ctx, authorId, asOf)
+	}
 
 	author, err := controller.authorService.FindByID(ctx.Context(), authorId)
 	if err != nil {
 		return err
 	}
 	if author.Id == "" {
-		return exception.ErrNotFound("Author not found")
+		return &domain.NotFoundError{Entity: domain.EntityAuthor}
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+	return sendConditional(ctx, entityTag(author.Version), author.UpdatedAt, web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
 		Message: "success",
@@ -91,22 +142,30 @@ func (controller *authorController) FindByID(ctx *fiber.Ctx) error {
 
 func (controller *authorController) UpdateAuthor(ctx *fiber.Ctx) error {
 	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
 
 	var request req.AuthorRequest
 	err := ctx.BodyParser(&request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 
 	err = controller.validate.Struct(request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
-	authorResponse, err := controller.authorService.UpdateAuthor(ctx.Context(), request, id)
+	version, err := ifMatchVersion(ctx, domain.EntityAuthor)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
+	}
+	authorResponse, err := controller.authorService.UpdateAuthor(ctx.Context(), request, id, version)
+	if err != nil {
+		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, entityTag(authorResponse.Version))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
@@ -115,26 +174,225 @@ func (controller *authorController) UpdateAuthor(ctx *fiber.Ctx) error {
 	})
 }
 
-func (controller *authorController) FindAllAuthor(ctx *fiber.Ctx) error {
-	authors, err := controller.authorService.FindAllAuthor(ctx.Context())
+func (controller *authorController) PatchAuthor(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+
+	patch, err := parsePatch(ctx)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityAuthor)
+	if err != nil {
+		return err
+	}
+	authorResponse, err := controller.authorService.PatchAuthor(ctx.Context(), patch, id, version)
+	if err != nil {
+		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, entityTag(authorResponse.Version))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
 		Message: "success",
-		Data:    authors,
+		Data:    authorResponse,
+	})
+}
+
+func (controller *authorController) FindAllAuthor(ctx *fiber.Ctx) error {
+	since, err := parseSince(ctx)
+	if err != nil {
+		return err
+	}
+	authors, err := controller.authorService.FindAllAuthor(ctx.Context(), since)
+	if err != nil {
+		return err
+	}
+
+	var data interface{} = authors
+	if !since.IsZero() {
+		// Authors deleted since then are only sent as tombstones.
+		changes := make([]interface{}, len(authors))
+		for i, author := range authors {
+			changes[i] = author
+			if author.DeletedAt != nil {
+				changes[i] = response.TombstoneResponse{Id: author.Id, DeletedAt: *author.DeletedAt}
+			}
+		}
+		data = changes
+	}
+	return sendConditional(ctx, "", time.Time{}, web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
 	})
 }
 
 func (controller *authorController) DeleteAuthor(ctx *fiber.Ctx) error {
 	id := ctx.Params("author_id")
-	data, err := controller.authorService.DeleteAuthor(ctx.Context(), id)
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+	var request req.DeleteAuthorRequest
+	if err := ctx.QueryParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityAuthor)
+	if err != nil {
+		return err
+	}
+	data, err := controller.authorService.DeleteAuthor(ctx.Context(), id, version, request)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+func (controller *authorController) RestoreAuthor(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+	authorResponse, err := controller.authorService.RestoreAuthor(ctx.Context(), id)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
 	}
+
+	ctx.Set(fiber.HeaderETag, entityTag(authorResponse.Version))
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    authorResponse,
+	})
+}
+
+func (controller *authorController) MergeAuthors(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+
+	var request req.MergeAuthorsRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityAuthor)
+	if err != nil {
+		return err
+	}
+	mergeResponse, err := controller.authorService.MergeAuthors(ctx.Context(), id, version, request)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, entityTag(mergeResponse.Author.Version))
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    mergeResponse,
+	})
+}
+
+// FindAuthorAsOf answers GET /authors/:author_id?as_of=<timestamp> with the author
+// as it was at that time. It needs history.read, so the response is private;
+// past versions never change, so no validators are sent.
+func (controller *authorController) FindAuthorAsOf(ctx *fiber.Ctx, id string, asOf time.Time) error {
+	if err := middleware.CheckPermission(ctx, domain.PermissionHistoryRead); err != nil {
+		return err
+	}
+	data, err := controller.authorService.FindAuthorAsOf(ctx.Context(), id, asOf)
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderCacheControl, "private, no-cache")
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+func (controller *authorController) FindAuthorVersions(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+	versions, err := controller.authorService.FindAuthorVersions(ctx.Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    versions,
+	})
+}
+
+func (controller *authorController) DiffAuthorVersions(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+	var request req.VersionDiffRequest
+	if err := ctx.QueryParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	diff, err := controller.authorService.DiffAuthorVersions(ctx.Context(), id, request.From, request.To)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    diff,
+	})
+}
+
+func (controller *authorController) RevertAuthor(ctx *fiber.Ctx) error {
+	id := ctx.Params("author_id")
+	if err := validateID(id, "author_id"); err != nil {
+		return err
+	}
+	var request req.RevertRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityAuthor)
+	if err != nil {
+		return err
+	}
+	data, err := controller.authorService.RevertAuthor(ctx.Context(), id, request.Version, version)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, entityTag(data.Version))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
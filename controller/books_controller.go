@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"time"
+
 	"test-backend-altech/config"
 	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
 	web "test-backend-altech/model/web"
 	req "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
 	"test-backend-altech/service"
 
 	"github.com/go-playground/validator/v10"
@@ -16,51 +21,80 @@ type BookController interface {
 }
 
 type bookController struct {
-	validate    *validator.Validate
-	bookService service.BookService
-	cache       config.Cache
+	validate     *validator.Validate
+	bookService  service.BookService
+	cache        config.Cache
+	cacheControl config.CacheControlConfig
+	idempotency  config.IdempotencyConfig
 }
 
-func NewBookController(validate *validator.Validate, bookService service.BookService, cache config.Cache) BookController {
+func NewBookController(validate *validator.Validate, bookService service.BookService, cache config.Cache, cacheControl config.CacheControlConfig, idempotency config.IdempotencyConfig) BookController {
 	return &bookController{
-		validate:    validate,
-		bookService: bookService,
-		cache:       cache,
+		validate:     validate,
+		bookService:  bookService,
+		cache:        cache,
+		cacheControl: cacheControl,
+		idempotency:  idempotency,
 	}
 }
 func (controller *bookController) Route(app *fiber.App) {
 	api := app.Group("/books")
 	api.Post("/",
+		middleware.RequirePermission(domain.PermissionBooksCreate),
+		middleware.Idempotency(controller.cache, controller.idempotency),
 		controller.CreateBook,
 	)
 	api.Get("/:book_id",
+		middleware.CacheControl(controller.cacheControl.BooksDetail),
 		controller.FindByID,
 	)
 	api.Put("/:book_id",
+		middleware.RequirePermission(domain.PermissionBooksUpdate),
 		controller.UpdateBook)
+	api.Patch("/:book_id",
+		middleware.RequirePermission(domain.PermissionBooksUpdate),
+		controller.PatchBook)
 
 	api.Get("/",
+		middleware.CacheControl(controller.cacheControl.BooksList),
 		controller.FindAllBook,
 	)
 
 	api.Delete("/:book_id",
+		middleware.RequirePermission(domain.PermissionBooksDelete),
 		controller.DeleteBook,
 	)
+	api.Post("/:book_id/restore",
+		middleware.RequirePermission(domain.PermissionBooksDelete),
+		controller.RestoreBook,
+	)
+	api.Get("/:book_id/versions",
+		middleware.RequirePermission(domain.PermissionHistoryRead),
+		controller.FindBookVersions,
+	)
+	api.Get("/:book_id/diff",
+		middleware.RequirePermission(domain.PermissionHistoryRead),
+		controller.DiffBookVersions,
+	)
+	api.Post("/:book_id/revert",
+		middleware.RequirePermission(domain.PermissionBooksUpdate),
+		controller.RevertBook,
+	)
 }
 func (controller *bookController) CreateBook(ctx *fiber.Ctx) error {
 	var request req.BookRequest
 	err := ctx.BodyParser(&request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 
 	err = controller.validate.Struct(request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 	bookResponse, err := controller.bookService.CreateBook(ctx.Context(), request)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
 	}
 
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
@@ -74,16 +108,27 @@ func (controller *bookController) CreateBook(ctx *fiber.Ctx) error {
 func (controller *bookController) FindByID(ctx *fiber.Ctx) error {
 
 	bookId := ctx.Params("book_id")
+	if err := validateID(bookId, "book_id"); err != nil {
+		return err
+	}
+	asOf, err := parseTimeQuery(ctx, "as_of")
+	if err != nil {
+		return err
+	}
+	if !asOf.IsZero() {
+		return controller.FindBookAsOf(ctx, bookId, asOf)
+	}
 
 	book, err := controller.bookService.FindByID(ctx.Context(), bookId)
 	if err != nil {
 		return err
 	}
 	if book.Id == "" {
-		return exception.ErrNotFound("Book not found")
+		return &domain.NotFoundError{Entity: domain.EntityBook}
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+	etag := entityTag(book.Version, book.AuthorVersion)
+	return sendConditional(ctx, etag, latest(book.UpdatedAt, book.AuthorUpdatedAt), web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
 		Message: "success",
@@ -93,22 +138,30 @@ func (controller *bookController) FindByID(ctx *fiber.Ctx) error {
 
 func (controller *bookController) UpdateBook(ctx *fiber.Ctx) error {
 	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
 
 	var request req.BookRequest
 	err := ctx.BodyParser(&request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
 
 	err = controller.validate.Struct(request)
 	if err != nil {
-		return exception.ErrValidateBadRequest(err.Error(), request)
+		return exception.ErrValidateBadRequest(err)
 	}
-	bookResponse, err := controller.bookService.UpdateBook(ctx.Context(), request, id)
+	version, err := ifMatchVersion(ctx, domain.EntityBook)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
+	}
+	bookResponse, err := controller.bookService.UpdateBook(ctx.Context(), request, id, version)
+	if err != nil {
+		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, entityTag(bookResponse.Version, bookResponse.AuthorVersion))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
@@ -117,26 +170,189 @@ func (controller *bookController) UpdateBook(ctx *fiber.Ctx) error {
 	})
 }
 
-func (controller *bookController) FindAllBook(ctx *fiber.Ctx) error {
-	books, err := controller.bookService.FindAllBook(ctx.Context(), controller.cache)
+func (controller *bookController) PatchBook(ctx *fiber.Ctx) error {
+	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+
+	patch, err := parsePatch(ctx)
+	if err != nil {
+		return err
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityBook)
+	if err != nil {
+		return err
+	}
+	bookResponse, err := controller.bookService.PatchBook(ctx.Context(), patch, id, version)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
 	}
 
+	ctx.Set(fiber.HeaderETag, entityTag(bookResponse.Version, bookResponse.AuthorVersion))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
 		Message: "success",
-		Data:    books,
+		Data:    bookResponse,
+	})
+}
+
+func (controller *bookController) FindAllBook(ctx *fiber.Ctx) error {
+	since, err := parseSince(ctx)
+	if err != nil {
+		return err
+	}
+	books, err := controller.bookService.FindAllBook(ctx.Context(), controller.cache, since)
+	if err != nil {
+		return err
+	}
+
+	var data interface{} = books
+	if !since.IsZero() {
+		// Books deleted since then are only sent as tombstones.
+		changes := make([]interface{}, len(books))
+		for i, book := range books {
+			changes[i] = book
+			if book.DeletedAt != nil {
+				changes[i] = response.TombstoneResponse{Id: book.Id, DeletedAt: *book.DeletedAt}
+			}
+		}
+		data = changes
+	}
+	// The list has no Last-Modified: deleting a book does not move the
+	// newest updated_at, so only the content hash is reliable.
+	return sendConditional(ctx, "", time.Time{}, web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
 	})
 }
 
 func (controller *bookController) DeleteBook(ctx *fiber.Ctx) error {
 	id := ctx.Params("book_id")
-	data, err := controller.bookService.DeleteBook(ctx.Context(), id)
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityBook)
+	if err != nil {
+		return err
+	}
+	data, err := controller.bookService.DeleteBook(ctx.Context(), id, version)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+func (controller *bookController) RestoreBook(ctx *fiber.Ctx) error {
+	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+	bookResponse, err := controller.bookService.RestoreBook(ctx.Context(), id)
 	if err != nil {
-		return exception.ErrorHandler(ctx, err)
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, entityTag(bookResponse.Version, bookResponse.AuthorVersion))
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    bookResponse,
+	})
+}
+
+// FindBookAsOf answers GET /books/:book_id?as_of=<timestamp> with the book
+// as it was at that time. It needs history.read, so the response is private;
+// past versions never change, so no validators are sent.
+func (controller *bookController) FindBookAsOf(ctx *fiber.Ctx, id string, asOf time.Time) error {
+	if err := middleware.CheckPermission(ctx, domain.PermissionHistoryRead); err != nil {
+		return err
+	}
+	data, err := controller.bookService.FindBookAsOf(ctx.Context(), id, asOf)
+	if err != nil {
+		return err
 	}
+	ctx.Set(fiber.HeaderCacheControl, "private, no-cache")
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    data,
+	})
+}
+
+func (controller *bookController) FindBookVersions(ctx *fiber.Ctx) error {
+	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+	versions, err := controller.bookService.FindBookVersions(ctx.Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    versions,
+	})
+}
+
+func (controller *bookController) DiffBookVersions(ctx *fiber.Ctx) error {
+	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+	var request req.VersionDiffRequest
+	if err := ctx.QueryParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	diff, err := controller.bookService.DiffBookVersions(ctx.Context(), id, request.From, request.To)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    diff,
+	})
+}
+
+func (controller *bookController) RevertBook(ctx *fiber.Ctx) error {
+	id := ctx.Params("book_id")
+	if err := validateID(id, "book_id"); err != nil {
+		return err
+	}
+	var request req.RevertRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	version, err := ifMatchVersion(ctx, domain.EntityBook)
+	if err != nil {
+		return err
+	}
+	data, err := controller.bookService.RevertBook(ctx.Context(), id, request.Version, version)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderETag, entityTag(data.Version, data.AuthorVersion))
 	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
 		Code:    fiber.StatusOK,
 		Status:  true,
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// contentTag returns a strong ETag derived from the bytes of a representation.
+func contentTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since. If-None-Match
+// takes precedence, If-Modified-Since is only used without it and when
+// lastModified is known.
+func notModified(ctx *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if header := ctx.Get(fiber.HeaderIfNoneMatch); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ctx.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// sendConditional replies 304 when the client already has the current
+// representation of data and sends it as JSON otherwise. An empty etag is
+// computed from the encoded body.
+func sendConditional(ctx *fiber.Ctx, etag string, lastModified time.Time, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if etag == "" {
+		etag = contentTag(body)
+	}
+
+	ctx.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(ctx, etag, lastModified) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Status(fiber.StatusOK).Send(body)
+}
+
+// latest returns the most recent of times.
+func latest(times ...time.Time) time.Time {
+	var max time.Time
+	for _, t := range times {
+		if t.After(max) {
+			max = t
+		}
+	}
+	return max
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// entityTag formats the version of an entity as a strong ETag. Versions of
+// related entities that are part of the representation, such as the author
+// of a book, follow the entity's own version.
+func entityTag(version int, related ...int) string {
+	tag := strconv.Itoa(version)
+	for _, v := range related {
+		tag += fmt.Sprintf(".%d", v)
+	}
+	return `"` + tag + `"`
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or 0
+// when the header is absent or "*". Only the entity's own version is checked.
+// Tags that can never match a stored version, including weak tags, fail the
+// precondition right away.
+func ifMatchVersion(ctx *fiber.Ctx, entity string) (int, error) {
+	header := strings.TrimSpace(ctx.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.SplitN(strings.Trim(header, `"`), ".", 2)[0])
+	if err != nil || version <= 0 || !strings.HasPrefix(header, `"`) {
+		return 0, &domain.PreconditionFailedError{Entity: entity}
+	}
+	return version, nil
+}
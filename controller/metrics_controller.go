@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	"test-backend-altech/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MetricsController interface {
+	Route(app *fiber.App)
+}
+
+type metricsController struct {
+	store repository.Store
+}
+
+func NewMetricsController(store repository.Store) MetricsController {
+	return &metricsController{
+		store: store,
+	}
+}
+
+func (controller *metricsController) Route(app *fiber.App) {
+	api := app.Group("/metrics")
+	api.Get("/transactions",
+		middleware.RequirePermission(domain.PermissionMetricsRead),
+		controller.Transactions,
+	)
+}
+
+func (controller *metricsController) Transactions(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    controller.store.Metrics(),
+	})
+}
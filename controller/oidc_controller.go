@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	req "test-backend-altech/model/web/req"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OIDCController interface {
+	Route(app *fiber.App)
+}
+
+type oidcController struct {
+	validate    *validator.Validate
+	oidcService service.OIDCService
+}
+
+func NewOIDCController(validate *validator.Validate, oidcService service.OIDCService) OIDCController {
+	return &oidcController{
+		validate:    validate,
+		oidcService: oidcService,
+	}
+}
+
+func (controller *oidcController) Route(app *fiber.App) {
+	api := app.Group("/auth/oidc")
+	api.Get("/login",
+		controller.Login,
+	)
+	api.Get("/callback",
+		controller.Callback,
+	)
+}
+
+// Login redirects the browser to the identity provider.
+func (controller *oidcController) Login(ctx *fiber.Ctx) error {
+	location, err := controller.oidcService.AuthorizationURL(ctx.Context())
+	if err != nil {
+		return err
+	}
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Redirect(location, fiber.StatusFound)
+}
+
+func (controller *oidcController) Callback(ctx *fiber.Ctx) error {
+	var request req.OIDCCallbackRequest
+	if err := ctx.QueryParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	tokens, err := controller.oidcService.Callback(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+	return sendTokens(ctx, tokens)
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"strings"
+	"time"
+
+	"test-backend-altech/model/domain"
+	req "test-backend-altech/model/web/req"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// validateID makes sure a path parameter is a UUID before it reaches Postgres.
+func validateID(id string, field string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return &domain.InvalidInputError{Field: field, Reason: "must be a valid UUID", Err: err}
+	}
+	return nil
+}
+
+// parseSince reads the optional since query parameter used for incremental
+// sync. It must be an RFC 3339 timestamp, the zero time means no filter.
+func parseSince(ctx *fiber.Ctx) (time.Time, error) {
+	return parseTimeQuery(ctx, "since")
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp from the query string,
+// the zero time means the parameter was not given.
+func parseTimeQuery(ctx *fiber.Ctx, field string) (time.Time, error) {
+	value := ctx.Query(field)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, &domain.InvalidInputError{Field: field, Reason: "must be an RFC 3339 timestamp", Err: err}
+	}
+	return t, nil
+}
+
+// parsePatch reads a PATCH body. JSON patch is used for
+// application/json-patch+json, merge patch for merge-patch+json and plain JSON.
+func parsePatch(ctx *fiber.Ctx) (req.PatchRequest, error) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(ctx.Get(fiber.HeaderContentType), ";", 2)[0]))
+	switch mediaType {
+	case req.JSONPatchMediaType, req.MergePatchMediaType:
+	case fiber.MIMEApplicationJSON:
+		mediaType = req.MergePatchMediaType
+	default:
+		return req.PatchRequest{}, fiber.ErrUnsupportedMediaType
+	}
+
+	return req.PatchRequest{
+		MediaType: mediaType,
+		Document:  append([]byte(nil), ctx.Body()...),
+	}, nil
+}
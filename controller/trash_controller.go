@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	"test-backend-altech/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TrashController interface {
+	Route(app *fiber.App)
+}
+
+type trashController struct {
+	trashService service.TrashService
+}
+
+func NewTrashController(trashService service.TrashService) TrashController {
+	return &trashController{
+		trashService: trashService,
+	}
+}
+
+func (controller *trashController) Route(app *fiber.App) {
+	api := app.Group("/trash")
+	api.Get("/",
+		middleware.RequirePermission(domain.PermissionTrashRead),
+		controller.FindTrash,
+	)
+}
+
+func (controller *trashController) FindTrash(ctx *fiber.Ctx) error {
+	trash, err := controller.trashService.FindTrash(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    trash,
+	})
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"test-backend-altech/exception"
+	"test-backend-altech/middleware"
+	"test-backend-altech/model/domain"
+	web "test-backend-altech/model/web"
+	req "test-backend-altech/model/web/req"
+	"test-backend-altech/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UserController interface {
+	Route(app *fiber.App)
+}
+
+type userController struct {
+	validate    *validator.Validate
+	userService service.UserService
+}
+
+func NewUserController(validate *validator.Validate, userService service.UserService) UserController {
+	return &userController{
+		validate:    validate,
+		userService: userService,
+	}
+}
+
+func (controller *userController) Route(app *fiber.App) {
+	api := app.Group("/users")
+	api.Post("/",
+		middleware.RequirePermission(domain.PermissionUsersManage),
+		controller.CreateUser,
+	)
+}
+
+func (controller *userController) CreateUser(ctx *fiber.Ctx) error {
+	var request req.UserRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	if err := controller.validate.Struct(request); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+
+	userResponse, err := controller.userService.CreateUser(ctx.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(web.WebResponse{
+		Code:    fiber.StatusOK,
+		Status:  true,
+		Message: "success",
+		Data:    userResponse,
+	})
+}
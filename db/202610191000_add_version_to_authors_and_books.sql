@@ -0,0 +1,2 @@
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;  -- Incremented on every update, used for ETag / If-Match
+ALTER TABLE books ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;    -- Incremented on every update, used for ETag / If-Match
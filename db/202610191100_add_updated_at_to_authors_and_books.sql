@@ -0,0 +1,17 @@
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now());  -- Last modification time, kept by the trigger below
+ALTER TABLE books ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now());    -- Last modification time, kept by the trigger below
+
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = timezone('utc', now());
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS authors_set_updated_at ON authors;
+CREATE TRIGGER authors_set_updated_at BEFORE UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+DROP TRIGGER IF EXISTS books_set_updated_at ON books;
+CREATE TRIGGER books_set_updated_at BEFORE UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
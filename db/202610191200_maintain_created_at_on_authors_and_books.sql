@@ -0,0 +1,16 @@
+UPDATE authors SET created_at = updated_at WHERE created_at IS NULL;
+UPDATE books SET created_at = updated_at WHERE created_at IS NULL;
+ALTER TABLE authors ALTER COLUMN created_at SET DEFAULT timezone('utc', now()), ALTER COLUMN created_at SET NOT NULL;  -- Set by the database on insert
+ALTER TABLE books ALTER COLUMN created_at SET DEFAULT timezone('utc', now()), ALTER COLUMN created_at SET NOT NULL;    -- Set by the database on insert
+
+-- created_at never changes after insert, updated_at is refreshed on every update.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = timezone('utc', now());
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE INDEX IF NOT EXISTS authors_updated_at_idx ON authors (updated_at, id);  -- Used by ?since= on GET /authors
+CREATE INDEX IF NOT EXISTS books_updated_at_idx ON books (updated_at, id);      -- Used by ?since= on GET /books
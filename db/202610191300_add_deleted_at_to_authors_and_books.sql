@@ -0,0 +1,5 @@
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;  -- Set when the author is moved to the trash, NULL while active
+ALTER TABLE books ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;    -- Set when the book is moved to the trash, NULL while active
+
+CREATE INDEX IF NOT EXISTS authors_deleted_at_idx ON authors (deleted_at) WHERE deleted_at IS NOT NULL;  -- Used by the trash listing and the purge job
+CREATE INDEX IF NOT EXISTS books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;      -- Used by the trash listing and the purge job
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS author_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),                -- UUID as primary key with auto-generation
+    survivor_id UUID NOT NULL,                                     -- Author that was kept
+    duplicate_ids UUID[] NOT NULL,                                 -- Authors that were merged into the survivor and moved to the trash
+    precedence VARCHAR(16) NOT NULL,                               -- Which side won when bio / birth_date differed
+    books_moved INTEGER NOT NULL DEFAULT 0,                        -- Number of books moved to the survivor
+    merged_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),   -- Time of the merge
+    CONSTRAINT fk_survivor FOREIGN KEY (survivor_id) REFERENCES authors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS author_merges_survivor_id_idx ON author_merges (survivor_id);
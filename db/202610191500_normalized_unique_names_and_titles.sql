@@ -0,0 +1,28 @@
+-- Author names and book titles are compared in the form domain.NormalizeName
+-- gives them: Unicode NFC, full case folding, trimmed and inner whitespace
+-- collapsed to one space. The API writes that form next to every name and
+-- title; rows written before these columns existed are filled in on startup
+-- by config.backfillNormalizedNames, which also makes the columns NOT NULL.
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS name_normalized TEXT;
+ALTER TABLE books ADD COLUMN IF NOT EXISTS title_normalized TEXT;
+
+-- Earlier versions of this migration indexed the SQL function
+-- catalog_normalize, which only lowercased. Those indexes go, the ones below
+-- take their names.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'authors_name_normalized_key' AND indexdef LIKE '%catalog_normalize%') THEN
+        DROP INDEX authors_name_normalized_key;
+    END IF;
+    IF EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'books_author_title_normalized_key' AND indexdef LIKE '%catalog_normalize%') THEN
+        DROP INDEX books_author_title_normalized_key;
+    END IF;
+END;
+$$;
+DROP FUNCTION IF EXISTS catalog_normalize(TEXT);
+
+-- Author names are unique among authors that are not in the trash, book
+-- titles per author.
+CREATE UNIQUE INDEX IF NOT EXISTS authors_name_normalized_key ON authors (name_normalized) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS books_author_title_normalized_key ON books (author_id, title_normalized) WHERE deleted_at IS NULL;
+ALTER TABLE authors DROP CONSTRAINT IF EXISTS authors_name_key;
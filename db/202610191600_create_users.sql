@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),                  -- UUID as primary key with auto-generation
+    email VARCHAR(255) NOT NULL,                                     -- Login name, unique ignoring case
+    name VARCHAR(255) NOT NULL DEFAULT '',                           -- Display name
+    password_hash TEXT NOT NULL,                                     -- bcrypt hash of the password
+    version INTEGER NOT NULL DEFAULT 1,                              -- Incremented on every update
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),    -- Set by the database on insert
+    updated_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())     -- Last modification time, kept by the trigger below
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_normalized_key ON users (lower(btrim(email)));
+
+DROP TRIGGER IF EXISTS users_set_updated_at ON users;
+CREATE TRIGGER users_set_updated_at BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
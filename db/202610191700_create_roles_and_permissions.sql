@@ -0,0 +1,67 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(64) PRIMARY KEY,                                    -- Role name such as reader, editor or admin
+    description TEXT NOT NULL DEFAULT '',                            -- What the role is meant for
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())     -- Set by the database on insert
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(64) PRIMARY KEY,                                    -- Permission checked by the API, such as books.create
+    description TEXT NOT NULL DEFAULT ''                             -- What the permission allows
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(64) NOT NULL,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role, permission),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission) REFERENCES permissions(name) ON DELETE CASCADE
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('reader', 'Can read the catalog'),
+    ('editor', 'Can create and update authors and books'),
+    ('admin', 'Can do everything, including deleting and merging')
+ON CONFLICT (name) DO NOTHING;
+
+-- A permission is granted to its default roles only when it is first
+-- inserted, so changes made through the admin endpoints survive restarts.
+WITH defaults (permission, description, roles) AS (VALUES
+    ('authors.create', 'Create authors', ARRAY['editor', 'admin']),
+    ('authors.update', 'Update authors', ARRAY['editor', 'admin']),
+    ('authors.delete', 'Delete and restore authors', ARRAY['admin']),
+    ('authors.merge', 'Merge duplicate authors', ARRAY['admin']),
+    ('books.create', 'Create books', ARRAY['editor', 'admin']),
+    ('books.update', 'Update books', ARRAY['editor', 'admin']),
+    ('books.delete', 'Delete and restore books', ARRAY['admin']),
+    ('trash.read', 'List deleted authors and books', ARRAY['editor', 'admin']),
+    ('users.manage', 'Create users and assign their roles', ARRAY['admin']),
+    ('roles.manage', 'Manage roles and their permissions', ARRAY['admin'])
+), inserted AS (
+    INSERT INTO permissions (name, description)
+    SELECT permission, description FROM defaults
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, d.permission
+FROM defaults AS d
+JOIN inserted AS i ON i.name = d.permission
+JOIN roles AS r ON r.name = ANY (d.roles)
+ON CONFLICT DO NOTHING;
+
+-- Users that existed before roles could write everything, so they start out
+-- as admins. This only happens when the table is first created.
+DO $$
+BEGIN
+    IF to_regclass('public.user_roles') IS NULL THEN
+        CREATE TABLE user_roles (
+            user_id UUID NOT NULL,
+            role VARCHAR(64) NOT NULL,
+            PRIMARY KEY (user_id, role),
+            CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+            CONSTRAINT fk_user_roles_role FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE
+        );
+        INSERT INTO user_roles (user_id, role) SELECT id, 'admin' FROM users;
+    END IF;
+END;
+$$;
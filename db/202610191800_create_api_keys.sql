@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),                  -- UUID as primary key with auto-generation
+    user_id UUID NOT NULL,                                           -- Owner, the key never grants more than its owner holds
+    name VARCHAR(100) NOT NULL,                                      -- Label chosen by the owner
+    prefix VARCHAR(32) NOT NULL,                                     -- Public part of the key, shown in listings
+    secret_hash TEXT NOT NULL,                                       -- SHA-256 of the secret part, hex encoded
+    scopes TEXT[] NOT NULL DEFAULT '{}',                             -- Scopes such as books:write
+    expires_at TIMESTAMP,                                            -- NULL when the key does not expire
+    last_used_at TIMESTAMP,                                          -- Last authenticated request, kept to the minute
+    revoked_at TIMESTAMP,                                            -- Set when the key is revoked
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),    -- Set by the database on insert
+    CONSTRAINT fk_api_keys_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_prefix_key ON api_keys (prefix);
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id, created_at);
+
+-- Like the other permissions, apikeys.manage only goes to its default roles
+-- when it is first inserted.
+WITH defaults (permission, description, roles) AS (VALUES
+    ('apikeys.manage', 'Create and revoke own API keys', ARRAY['editor', 'admin'])
+), inserted AS (
+    INSERT INTO permissions (name, description)
+    SELECT permission, description FROM defaults
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, d.permission
+FROM defaults AS d
+JOIN inserted AS i ON i.name = d.permission
+JOIN roles AS r ON r.name = ANY (d.roles)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,56 @@
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS created_by UUID;  -- User that created the author, NULL when unknown
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS updated_by UUID;  -- User that last changed the author, NULL when unknown
+ALTER TABLE books ADD COLUMN IF NOT EXISTS created_by UUID;    -- User that created the book, NULL when unknown
+ALTER TABLE books ADD COLUMN IF NOT EXISTS updated_by UUID;    -- User that last changed the book, NULL when unknown
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_authors_created_by') THEN
+        ALTER TABLE authors
+            ADD CONSTRAINT fk_authors_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+            ADD CONSTRAINT fk_authors_updated_by FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+        ALTER TABLE books
+            ADD CONSTRAINT fk_books_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+            ADD CONSTRAINT fk_books_updated_by FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL;
+    END IF;
+END;
+$$;
+
+-- The API stores the caller of every transaction in app.user_id, so every
+-- write, including deletes, restores and merges, records who made it.
+-- Writes without a caller, such as the purge job, leave updated_by NULL.
+CREATE OR REPLACE FUNCTION set_record_actor() RETURNS TRIGGER AS $$
+DECLARE
+    actor UUID := NULLIF(current_setting('app.user_id', true), '')::UUID;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.created_by = actor;
+    END IF;
+    NEW.updated_by = actor;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS authors_set_record_actor ON authors;
+CREATE TRIGGER authors_set_record_actor BEFORE INSERT OR UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION set_record_actor();
+
+DROP TRIGGER IF EXISTS books_set_record_actor ON books;
+CREATE TRIGGER books_set_record_actor BEFORE INSERT OR UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION set_record_actor();
+
+WITH defaults (permission, description, roles) AS (VALUES
+    ('authors.update_any', 'Update authors created by other users', ARRAY['admin']),
+    ('books.update_any', 'Update books created by other users', ARRAY['admin'])
+), inserted AS (
+    INSERT INTO permissions (name, description)
+    SELECT permission, description FROM defaults
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, d.permission
+FROM defaults AS d
+JOIN inserted AS i ON i.name = d.permission
+JOIN roles AS r ON r.name = ANY (d.roles)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    actor_id UUID,                                                   -- User that made the change, NULL for system changes; no foreign key so entries outlive users
+    api_key_id UUID,                                                 -- API key the actor used, if any
+    request_id TEXT NOT NULL DEFAULT '',                             -- X-Request-ID of the request that made the change
+    action VARCHAR(16) NOT NULL,                                     -- create, update, delete, restore or merge
+    entity VARCHAR(32) NOT NULL,                                     -- author or book
+    entity_id UUID NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',                             -- Changed fields as {"field": {"before": ..., "after": ...}}
+    details JSONB NOT NULL DEFAULT '{}',                             -- Context of the change, such as the books moved by a merge
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now())     -- Set by the database on insert
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at, id);
+
+WITH defaults (permission, description, roles) AS (VALUES
+    ('audit.read', 'Read the audit log', ARRAY['admin'])
+), inserted AS (
+    INSERT INTO permissions (name, description)
+    SELECT permission, description FROM defaults
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, d.permission
+FROM defaults AS d
+JOIN inserted AS i ON i.name = d.permission
+JOIN roles AS r ON r.name = ANY (d.roles)
+ON CONFLICT DO NOTHING;
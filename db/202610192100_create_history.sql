@@ -0,0 +1,91 @@
+-- Every version of an author or book, written by the triggers below on each
+-- insert and update. Rows are never changed afterwards and outlive the purge
+-- of the record itself.
+CREATE TABLE IF NOT EXISTS authors_history (
+    id UUID NOT NULL,                     -- Id of the author
+    version INTEGER NOT NULL,             -- Version of the author this row describes
+    name VARCHAR(255) NOT NULL,
+    bio TEXT,
+    birth_date DATE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID,
+    updated_by UUID,
+    deleted_at TIMESTAMP,
+    valid_from TIMESTAMP NOT NULL,        -- When this version became current
+    PRIMARY KEY (id, version)
+);
+
+CREATE TABLE IF NOT EXISTS books_history (
+    id UUID NOT NULL,                     -- Id of the book
+    version INTEGER NOT NULL,             -- Version of the book this row describes
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    publish_date DATE,
+    author_id UUID,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by UUID,
+    updated_by UUID,
+    deleted_at TIMESTAMP,
+    valid_from TIMESTAMP NOT NULL,        -- When this version became current
+    PRIMARY KEY (id, version)
+);
+
+CREATE INDEX IF NOT EXISTS authors_history_valid_from_idx ON authors_history (id, valid_from);
+CREATE INDEX IF NOT EXISTS books_history_valid_from_idx ON books_history (id, valid_from);
+
+-- Writes that do not bump the version, such as clearing created_by when a
+-- user is deleted, keep the row recorded for that version.
+CREATE OR REPLACE FUNCTION record_author_version() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO authors_history (id, version, name, bio, birth_date, created_at, updated_at, created_by, updated_by, deleted_at, valid_from)
+    VALUES (NEW.id, NEW.version, NEW.name, NEW.bio, NEW.birth_date, NEW.created_at, NEW.updated_at, NEW.created_by, NEW.updated_by, NEW.deleted_at, NEW.updated_at)
+    ON CONFLICT (id, version) DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_book_version() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO books_history (id, version, title, description, publish_date, author_id, created_at, updated_at, created_by, updated_by, deleted_at, valid_from)
+    VALUES (NEW.id, NEW.version, NEW.title, NEW.description, NEW.publish_date, NEW.author_id, NEW.created_at, NEW.updated_at, NEW.created_by, NEW.updated_by, NEW.deleted_at, NEW.updated_at)
+    ON CONFLICT (id, version) DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS authors_record_version ON authors;
+CREATE TRIGGER authors_record_version AFTER INSERT OR UPDATE ON authors
+    FOR EACH ROW EXECUTE FUNCTION record_author_version();
+
+DROP TRIGGER IF EXISTS books_record_version ON books;
+CREATE TRIGGER books_record_version AFTER INSERT OR UPDATE ON books
+    FOR EACH ROW EXECUTE FUNCTION record_book_version();
+
+-- Records that existed before history was kept start with their current
+-- version.
+INSERT INTO authors_history (id, version, name, bio, birth_date, created_at, updated_at, created_by, updated_by, deleted_at, valid_from)
+SELECT id, version, name, bio, birth_date, created_at, updated_at, created_by, updated_by, deleted_at, updated_at
+FROM authors
+ON CONFLICT (id, version) DO NOTHING;
+
+INSERT INTO books_history (id, version, title, description, publish_date, author_id, created_at, updated_at, created_by, updated_by, deleted_at, valid_from)
+SELECT id, version, title, description, publish_date, author_id, created_at, updated_at, created_by, updated_by, deleted_at, updated_at
+FROM books
+ON CONFLICT (id, version) DO NOTHING;
+
+WITH defaults (permission, description, roles) AS (VALUES
+    ('history.read', 'Read past versions of authors and books', ARRAY['editor', 'admin'])
+), inserted AS (
+    INSERT INTO permissions (name, description)
+    SELECT permission, description FROM defaults
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, d.permission
+FROM defaults AS d
+JOIN inserted AS i ON i.name = d.permission
+JOIN roles AS r ON r.name = ANY (d.roles)
+ON CONFLICT DO NOTHING;
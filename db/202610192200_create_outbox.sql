@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,              -- Publishing order, events of one entity are published in id order
+    event_type VARCHAR(64) NOT NULL,                                 -- For example BookCreated or AuthorDeleted
+    aggregate_type VARCHAR(32) NOT NULL,                             -- author or book
+    aggregate_id UUID NOT NULL,                                      -- Id of the author or book the event is about
+    payload JSONB NOT NULL,                                          -- The record after the change, before it for deletes
+    actor_id UUID,                                                   -- User that made the change, NULL for system changes
+    request_id TEXT NOT NULL DEFAULT '',                             -- X-Request-ID of the request that made the change
+    created_at TIMESTAMP NOT NULL DEFAULT timezone('utc', now()),    -- Set by the database on insert
+    published_at TIMESTAMP,                                          -- Set by the relay once the event was published, NULL while pending
+    attempts INTEGER NOT NULL DEFAULT 0,                             -- Failed publish attempts
+    last_error TEXT                                                  -- Error of the last failed attempt
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE published_at IS NULL;                     -- Used by the relay
+CREATE INDEX IF NOT EXISTS outbox_published_at_idx ON outbox (published_at) WHERE published_at IS NOT NULL;  -- Used by the cleanup of published events
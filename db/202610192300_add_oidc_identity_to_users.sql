@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer TEXT;   -- Identity provider the user logs in with, NULL for local users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT;  -- sub claim of the user at that provider
+
+-- An identity of the provider belongs to one user only.
+CREATE UNIQUE INDEX IF NOT EXISTS users_oidc_identity_key ON users (oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
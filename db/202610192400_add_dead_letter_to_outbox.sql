@@ -0,0 +1,12 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMP;   -- Set after a failed attempt, the event is not retried before then
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP;  -- Set when the event failed OUTBOX_MAX_ATTEMPTS times, it is no longer relayed
+
+-- Pending events are neither published nor dead lettered. The relay reads
+-- them by id and looks up earlier pending events of the same entity.
+DROP INDEX IF EXISTS outbox_pending_idx;
+CREATE INDEX IF NOT EXISTS outbox_pending_id_idx ON outbox (id)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_pending_aggregate_idx ON outbox (aggregate_type, aggregate_id, id)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_dead_lettered_at_idx ON outbox (dead_lettered_at)
+    WHERE dead_lettered_at IS NOT NULL;
@@ -0,0 +1,127 @@
+package exception
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"test-backend-altech/model/domain"
+)
+
+// Stable machine readable error codes. Clients branch on these, so existing
+// values must never change meaning.
+const (
+	CodeBadRequest          = "BAD_REQUEST"
+	CodeInvalidInput        = "INVALID_INPUT"
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeNotFound            = "NOT_FOUND"
+	CodeConflict            = "CONFLICT"
+	CodeInvalidReference    = "INVALID_REFERENCE"
+	CodeUnprocessableEntity = "UNPROCESSABLE_ENTITY"
+	CodePreconditionFailed  = "PRECONDITION_FAILED"
+	CodeInternal            = "INTERNAL_ERROR"
+	CodeHasDependents       = "HAS_DEPENDENTS"
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeRateLimited         = "RATE_LIMITED"
+
+	CodeIdempotencyKeyInUse  = "IDEMPOTENCY_KEY_IN_USE"
+	CodeIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeInvalidToken       = "INVALID_TOKEN"
+	CodeTokenExpired       = "TOKEN_EXPIRED"
+	CodeTokenRevoked       = "TOKEN_REVOKED"
+
+	CodeAuthorNotFound          = "AUTHOR_NOT_FOUND"
+	CodeAuthorNameTaken         = "AUTHOR_NAME_TAKEN"
+	CodeAuthorBirthDateInFuture = "AUTHOR_BIRTH_DATE_IN_FUTURE"
+	CodeAuthorVersionMismatch   = "AUTHOR_VERSION_MISMATCH"
+	CodeAuthorHasBooks          = "AUTHOR_HAS_BOOKS"
+	CodeAuthorVersionNotFound   = "AUTHOR_VERSION_NOT_FOUND"
+
+	CodeBookNotFound                   = "BOOK_NOT_FOUND"
+	CodeBookTitleTaken                 = "BOOK_TITLE_TAKEN"
+	CodeBookAuthorUnknown              = "BOOK_AUTHOR_UNKNOWN"
+	CodeBookPublishedBeforeAuthorBirth = "BOOK_PUBLISHED_BEFORE_AUTHOR_BIRTH"
+	CodeBookVersionMismatch            = "BOOK_VERSION_MISMATCH"
+	CodeBookVersionNotFound            = "BOOK_VERSION_NOT_FOUND"
+
+	CodeUserNotFound   = "USER_NOT_FOUND"
+	CodeUserEmailTaken = "USER_EMAIL_TAKEN"
+
+	CodeRoleNotFound  = "ROLE_NOT_FOUND"
+	CodeRoleNameTaken = "ROLE_NAME_TAKEN"
+
+	CodeApiKeyNotFound = "API_KEY_NOT_FOUND"
+)
+
+var notFoundCodes = map[string]string{
+	domain.EntityAuthor: CodeAuthorNotFound,
+	domain.EntityBook:   CodeBookNotFound,
+	domain.EntityUser:   CodeUserNotFound,
+	domain.EntityRole:   CodeRoleNotFound,
+	domain.EntityApiKey: CodeApiKeyNotFound,
+
+	domain.EntityAuthorVersion: CodeAuthorVersionNotFound,
+	domain.EntityBookVersion:   CodeBookVersionNotFound,
+}
+
+var conflictCodes = map[string]string{
+	domain.EntityAuthor + ".name": CodeAuthorNameTaken,
+	domain.EntityBook + ".title":  CodeBookTitleTaken,
+	domain.EntityUser + ".email":  CodeUserEmailTaken,
+	domain.EntityRole + ".name":   CodeRoleNameTaken,
+}
+
+var invalidReferenceCodes = map[string]string{
+	domain.EntityBook + ".author_id": CodeBookAuthorUnknown,
+}
+
+var dependentsCodes = map[string]string{
+	domain.EntityAuthor + "." + domain.EntityBook: CodeAuthorHasBooks,
+}
+
+var preconditionCodes = map[string]string{
+	domain.EntityAuthor: CodeAuthorVersionMismatch,
+	domain.EntityBook:   CodeBookVersionMismatch,
+}
+
+var ruleCodes = map[string]string{
+	domain.RuleBirthDateInFuture:          CodeAuthorBirthDateInFuture,
+	domain.RulePublishedBeforeAuthorBirth: CodeBookPublishedBeforeAuthorBirth,
+}
+
+var unauthenticatedCodes = map[string]string{
+	domain.ReasonInvalidCredentials: CodeInvalidCredentials,
+	domain.ReasonInvalidToken:       CodeInvalidToken,
+	domain.ReasonTokenExpired:       CodeTokenExpired,
+	domain.ReasonTokenRevoked:       CodeTokenRevoked,
+}
+
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:          CodeBadRequest,
+	fiber.StatusUnauthorized:        CodeUnauthenticated,
+	fiber.StatusForbidden:           CodeForbidden,
+	fiber.StatusNotFound:            CodeNotFound,
+	fiber.StatusConflict:            CodeConflict,
+	fiber.StatusUnprocessableEntity: CodeUnprocessableEntity,
+	fiber.StatusPreconditionFailed:  CodePreconditionFailed,
+	fiber.StatusTooManyRequests:     CodeRateLimited,
+	fiber.StatusInternalServerError: CodeInternal,
+}
+
+func lookupCode(codes map[string]string, key string, fallback string) string {
+	if code, ok := codes[key]; ok {
+		return code
+	}
+	return fallback
+}
+
+// codeForStatus returns the generic code of an HTTP status.
+func codeForStatus(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -1,10 +1,16 @@
 package exception
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 
+	"test-backend-altech/model/civil"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -16,6 +22,10 @@ func ErrNotFound(message string) *fiber.Error {
 	return fiber.NewError(fiber.StatusNotFound, message)
 }
 
+func ErrConflict(message string) *fiber.Error {
+	return fiber.NewError(fiber.StatusConflict, message)
+}
+
 func ErrUnprocessableEntity(message string) *fiber.Error {
 	return fiber.NewError(fiber.StatusUnprocessableEntity, message)
 }
@@ -24,50 +34,93 @@ func ErrInternalServer(message string) *fiber.Error {
 	return fiber.NewError(fiber.StatusInternalServerError, message)
 }
 
-// ErrValidateBadRequest is a function to handle error validation
-func ErrValidateBadRequest(message string, data interface{}) *fiber.Error {
-	resMessage := message
-	if strings.Contains(message, "cannot unmarshal") && strings.Contains(message, " of type ") {
-		split := strings.Split(message, ".")
-		values := strings.Split(split[len(split)-1], " of type ")
-		resMessage = fmt.Sprintf("Field '%s' must be filled with a value of type %s", values[0], values[1])
-	} else if strings.Contains(message, "invalid character") {
-		resMessage = "Bad body request, check the JSON formatting"
-	} else if strings.Contains(message, "Error:Field validation") && strings.Contains(message, "' failed on the '") {
-		values := strings.Split(strings.Split(message, "validation for '")[1], "' failed on the '")
-		t := reflect.TypeOf(data)
-		field, _ := t.FieldByName(values[0])
-		validate := field.Tag.Get("validate")
-		tag := field.Tag.Get("json")
-
-		// check the validation error further
-		switch {
-		case strings.Contains(message, "'required'"):
-			resMessage = fmt.Sprintf("Field '%s' must be filled", tag)
-		case strings.Contains(message, "'max'"):
-			maxChar := strings.Split(strings.Split(validate, "max=")[1], ",")[0]
-			prefix := "character"
-			suffix := " character"
-			if field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Ptr {
-				prefix = "number"
-				suffix = " int"
-			}
-			resMessage = fmt.Sprintf("Field '%s' exceeded the maximum %s limit of: %s %s", tag, prefix, maxChar, suffix)
-		case strings.Contains(message, "'min'"):
-			prefix := "character"
-			suffix := "character"
-			if field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.Ptr {
-				prefix = "number"
-				suffix = "int"
-			}
-			minChar := strings.Split(strings.Split(validate, "min=")[1], ",")[0]
-			resMessage = fmt.Sprintf("Field '%s' exceeded the minimum %s limit of: %s %s", tag, prefix, minChar, suffix)
-		case strings.Contains(message, "failed on the"):
-			format := strings.Split(strings.Split(message, "failed on the '")[1], "' tag")[0]
-			resMessage = fmt.Sprintf("Field '%s' must have a '%s' format", tag, format)
-		default:
-			resMessage = "There's error on body requests"
+// ValidationError lists every field of a request body that failed validation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ErrValidateBadRequest is a function to handle errors from parsing and
+// validating a request body. Validator errors are reported field by field
+// using the JSON names of the fields.
+func ErrValidateBadRequest(err error) error {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var fiberErr *fiber.Error
+	var dateErr *civil.ParseError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, newFieldError(fieldErr))
 		}
+		return &ValidationError{Fields: fields}
+	case errors.As(err, &typeErr):
+		return &ValidationError{Fields: []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("Field '%s' must be filled with a value of type %s", typeErr.Field, typeErr.Type.String()),
+		}}}
+	case errors.As(err, &dateErr):
+		return ErrBadRequest(dateErr.Error())
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrBadRequest("Bad body request, check the JSON formatting")
+	case errors.As(err, &fiberErr):
+		return fiberErr
+	default:
+		return ErrBadRequest(err.Error())
+	}
+}
+
+func newFieldError(fieldErr validator.FieldError) FieldError {
+	// Namespace is "<Struct>.<json path>", drop the struct name.
+	field := fieldErr.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	return FieldError{
+		Field:   field,
+		Rule:    fieldErr.Tag(),
+		Param:   fieldErr.Param(),
+		Message: fieldMessage(field, fieldErr),
+		source:  fieldErr,
+	}
+}
+
+func fieldMessage(field string, fieldErr validator.FieldError) string {
+	prefix := "character"
+	switch fieldErr.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		prefix = "number"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		prefix = "item"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_without", "required_with", "required_if":
+		return fmt.Sprintf("Field '%s' must be filled", field)
+	case "max", "lte":
+		return fmt.Sprintf("Field '%s' exceeded the maximum %s limit of: %s", field, prefix, fieldErr.Param())
+	case "min", "gte":
+		return fmt.Sprintf("Field '%s' is below the minimum %s limit of: %s", field, prefix, fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("Field '%s' must be one of: %s", field, fieldErr.Param())
+	case "datetime":
+		return fmt.Sprintf("Field '%s' must have a '%s' format", field, fieldErr.Param())
+	default:
+		return fmt.Sprintf("Field '%s' must have a '%s' format", field, fieldErr.Tag())
 	}
-	return ErrBadRequest(resMessage)
 }
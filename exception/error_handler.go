@@ -1,26 +1,46 @@
 package exception
 
 import (
-	"errors"
-
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"test-backend-altech/model/web"
+	"test-backend-altech/utils"
 )
 
+var logger = utils.NewLogger()
+
+// ErrorHandler is registered as Fiber's ErrorHandler, so every error returned
+// by a handler or middleware is rendered here. Responses are problem+json
+// unless the client asks for the legacy WebResponse envelope.
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
+	problem := NewProblem(err)
+	problem.Instance = c.Path()
+	problem.RequestId, _ = c.Locals(requestid.ConfigDefault.ContextKey).(string)
+	problem.Localize(translatorOf(c))
+
+	if problem.Status == fiber.StatusUnauthorized {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+	}
 
-	var e *fiber.Error
-	if errors.As(err, &e) {
-		code = e.Code
+	if problem.Status >= fiber.StatusInternalServerError {
+		logger.Errorw("Request failed", "request_id", problem.RequestId, "path", problem.Instance, "error", err)
 	}
 
-	response := web.WebResponse{
-		Code:    code,
-		Status:  false,
-		Message: err.Error(),
-		Data:    err.Error(),
+	if c.Accepts(ProblemMediaType, LegacyMediaType) == LegacyMediaType {
+		response := web.WebResponse{
+			Code:    problem.Status,
+			Status:  false,
+			Message: problem.Detail,
+			Data:    problem.Detail,
+		}
+		if len(problem.Errors) > 0 {
+			response.Data = problem.Errors
+		}
+		if len(problem.Blocking) > 0 {
+			response.Data = problem.Blocking
+		}
+		return c.Status(problem.Status).JSON(response)
 	}
 
-	return c.Status(code).JSON(response)
+	return c.Status(problem.Status).JSON(problem, ProblemMediaType)
 }
@@ -0,0 +1,150 @@
+package exception
+
+// messages holds every translatable message of the API, keyed by locale.
+// Error codes are the keys of their own message; {0}, {1} are filled with the
+// entity and field of the error. Plain English sentences used as keys are
+// translated as is.
+var messages = map[string]map[string]string{
+	"en": {
+		CodeBadRequest:          "Bad request",
+		CodeInvalidInput:        "Field '{0}' {1}",
+		CodeValidationFailed:    "One or more fields are invalid",
+		CodeNotFound:            "{0} not found",
+		CodeConflict:            "{0} {1} already exists",
+		CodeInvalidReference:    "{0} {1} references a record that does not exist",
+		CodeUnprocessableEntity: "Unprocessable entity",
+		CodePreconditionFailed:  "{0} was modified by another request",
+		CodeInternal:            "Internal server error",
+		CodeHasDependents:       "{0} is still referenced by {1} records",
+		CodeUnauthenticated:     "Authentication is required",
+		CodeForbidden:           "Permission '{0}' is required",
+		CodeRateLimited:         "Too many requests, retry later",
+
+		CodeIdempotencyKeyInUse:  "A request with this Idempotency-Key is still in progress",
+		CodeIdempotencyKeyReused: "Idempotency-Key was already used with a different request body",
+
+		CodeInvalidCredentials: "Invalid email or password",
+		CodeInvalidToken:       "Token is invalid",
+		CodeTokenExpired:       "Token has expired",
+		CodeTokenRevoked:       "Token has been revoked",
+
+		CodeAuthorNotFound:          "Author not found",
+		CodeAuthorNameTaken:         "Author name already exists",
+		CodeAuthorBirthDateInFuture: "Author birth_date can not be in the future",
+		CodeAuthorVersionMismatch:   "Author was modified by another request, fetch it again and retry",
+		CodeAuthorHasBooks:          "Author still has books, delete them or move them to another author first",
+		CodeAuthorVersionNotFound:   "Author version not found",
+
+		CodeBookNotFound:                   "Book not found",
+		CodeBookTitleTaken:                 "Book title already exists",
+		CodeBookAuthorUnknown:              "Book author_id references an author that does not exist",
+		CodeBookPublishedBeforeAuthorBirth: "Book publish_date can not be before the birth_date of its author",
+		CodeBookVersionMismatch:            "Book was modified by another request, fetch it again and retry",
+		CodeBookVersionNotFound:            "Book version not found",
+
+		CodeUserNotFound:   "User not found",
+		CodeUserEmailTaken: "User email already exists",
+
+		CodeRoleNotFound:  "Role not found",
+		CodeRoleNameTaken: "Role name already exists",
+
+		CodeApiKeyNotFound: "API key not found",
+
+		"Author": "Author",
+		"Book":   "Book",
+		"User":   "User",
+		"Role":   "Role",
+		"ApiKey": "API key",
+
+		"AuthorVersion": "Author version",
+		"BookVersion":   "Book version",
+
+		"must be filled":                        "must be filled",
+		"is too long":                           "is too long",
+		"must be a valid date":                  "must be a valid date",
+		"must be a valid UUID":                  "must be a valid UUID",
+		"must be an RFC 3339 timestamp":         "must be an RFC 3339 timestamp",
+		"must be another author":                "must be another author",
+		"must not contain the surviving author": "must not contain the surviving author",
+		"is a built-in role":                    "is a built-in role",
+		"must be in the future":                 "must be in the future",
+
+		"Bad body request, check the JSON formatting": "Bad body request, check the JSON formatting",
+	},
+	"id": {
+		CodeBadRequest:          "Permintaan tidak valid",
+		CodeInvalidInput:        "Kolom '{0}' {1}",
+		CodeValidationFailed:    "Satu atau lebih kolom tidak valid",
+		CodeNotFound:            "{0} tidak ditemukan",
+		CodeConflict:            "{1} {0} sudah ada",
+		CodeInvalidReference:    "{1} {0} merujuk ke data yang tidak ada",
+		CodeUnprocessableEntity: "Data tidak dapat diproses",
+		CodePreconditionFailed:  "{0} telah diubah oleh permintaan lain",
+		CodeInternal:            "Terjadi kesalahan pada server",
+		CodeHasDependents:       "{0} masih dirujuk oleh data {1}",
+		CodeUnauthenticated:     "Autentikasi diperlukan",
+		CodeForbidden:           "Izin '{0}' diperlukan",
+		CodeRateLimited:         "Terlalu banyak permintaan, coba lagi nanti",
+
+		CodeIdempotencyKeyInUse:  "Permintaan dengan Idempotency-Key ini masih diproses",
+		CodeIdempotencyKeyReused: "Idempotency-Key sudah digunakan dengan body permintaan yang berbeda",
+
+		CodeInvalidCredentials: "Email atau kata sandi salah",
+		CodeInvalidToken:       "Token tidak valid",
+		CodeTokenExpired:       "Token sudah kedaluwarsa",
+		CodeTokenRevoked:       "Token sudah dicabut",
+
+		CodeAuthorNotFound:          "Penulis tidak ditemukan",
+		CodeAuthorNameTaken:         "Nama penulis sudah digunakan",
+		CodeAuthorBirthDateInFuture: "birth_date penulis tidak boleh di masa depan",
+		CodeAuthorVersionMismatch:   "Penulis telah diubah oleh permintaan lain, ambil ulang datanya lalu coba lagi",
+		CodeAuthorHasBooks:          "Penulis masih memiliki buku, hapus atau pindahkan buku tersebut ke penulis lain terlebih dahulu",
+		CodeAuthorVersionNotFound:   "Versi penulis tidak ditemukan",
+
+		CodeBookNotFound:                   "Buku tidak ditemukan",
+		CodeBookTitleTaken:                 "Judul buku sudah digunakan",
+		CodeBookAuthorUnknown:              "author_id buku merujuk ke penulis yang tidak ada",
+		CodeBookPublishedBeforeAuthorBirth: "publish_date buku tidak boleh sebelum birth_date penulisnya",
+		CodeBookVersionMismatch:            "Buku telah diubah oleh permintaan lain, ambil ulang datanya lalu coba lagi",
+		CodeBookVersionNotFound:            "Versi buku tidak ditemukan",
+
+		CodeUserNotFound:   "Pengguna tidak ditemukan",
+		CodeUserEmailTaken: "Email pengguna sudah digunakan",
+
+		CodeRoleNotFound:  "Peran tidak ditemukan",
+		CodeRoleNameTaken: "Nama peran sudah digunakan",
+
+		CodeApiKeyNotFound: "API key tidak ditemukan",
+
+		"Author": "Penulis",
+		"Book":   "Buku",
+		"User":   "Pengguna",
+		"Role":   "Peran",
+		"ApiKey": "API key",
+
+		"AuthorVersion": "Versi penulis",
+		"BookVersion":   "Versi buku",
+
+		"must be filled":                        "wajib diisi",
+		"is too long":                           "terlalu panjang",
+		"must be a valid date":                  "harus berupa tanggal yang valid",
+		"must be a valid UUID":                  "harus berupa UUID yang valid",
+		"must be an RFC 3339 timestamp":         "harus berupa timestamp RFC 3339",
+		"must be another author":                "harus penulis yang lain",
+		"must not contain the surviving author": "tidak boleh memuat penulis yang dipertahankan",
+		"is a built-in role":                    "adalah peran bawaan",
+		"must be in the future":                 "harus di masa depan",
+
+		"Bad body request, check the JSON formatting": "Body permintaan tidak valid, periksa format JSON",
+	},
+}
+
+// validationMessages fills in validator tags that go-playground does not
+// translate for a locale.
+var validationMessages = map[string]map[string]string{
+	"id": {
+		"required_without": "{0} wajib diisi",
+		"required_if":      "{0} wajib diisi",
+		"datetime":         "{0} harus memiliki format {1}",
+	},
+}
@@ -0,0 +1,198 @@
+package exception
+
+import (
+	"errors"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"test-backend-altech/model/domain"
+)
+
+const (
+	ProblemMediaType = "application/problem+json"
+	// LegacyMediaType lets clients opt back into the WebResponse envelope.
+	LegacyMediaType = "application/vnd.webresponse+json"
+)
+
+// Problem is an RFC 7807 problem details document extended with a stable
+// error code, the request id and field level details.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestId string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Blocking lists the records that prevent a delete.
+	Blocking []domain.Reference `json:"blocking,omitempty"`
+
+	message message
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+
+	source  validator.FieldError
+	message message
+}
+
+// message is the catalog key and parameters a text was built from, so it can
+// be translated once the locale of the request is known.
+type message struct {
+	key    string
+	params []string
+}
+
+// NewProblem describes err as a problem document. Domain errors get their
+// own status and code, *fiber.Error keeps its status and anything else is
+// reported as an internal error.
+func NewProblem(err error) Problem {
+	var fiberErr *fiber.Error
+	var validationErr *ValidationError
+	var notFoundErr *domain.NotFoundError
+	var conflictErr *domain.ConflictError
+	var invalidReferenceErr *domain.InvalidReferenceError
+	var invalidInputErr *domain.InvalidInputError
+	var ruleViolationErr *domain.RuleViolationError
+	var preconditionErr *domain.PreconditionFailedError
+	var dependentsErr *domain.DependentsError
+	var unauthenticatedErr *domain.UnauthenticatedError
+	var forbiddenErr *domain.ForbiddenError
+	var rateLimitedErr *domain.RateLimitedError
+	var idempotencyErr *domain.IdempotencyError
+
+	switch {
+	case errors.As(err, &validationErr):
+		problem := newProblem(fiber.StatusBadRequest, CodeValidationFailed, "One or more fields are invalid",
+			message{key: CodeValidationFailed})
+		problem.Errors = validationErr.Fields
+		return problem
+	case errors.As(err, &fiberErr):
+		return newProblem(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message,
+			message{key: fiberErr.Message})
+	case errors.As(err, &unauthenticatedErr):
+		code := lookupCode(unauthenticatedCodes, unauthenticatedErr.Reason, CodeUnauthenticated)
+		return newProblem(fiber.StatusUnauthorized, code, unauthenticatedErr.Error(),
+			message{key: code})
+	case errors.As(err, &forbiddenErr):
+		return newProblem(fiber.StatusForbidden, CodeForbidden, forbiddenErr.Error(),
+			message{key: CodeForbidden, params: []string{forbiddenErr.Permission}})
+	case errors.As(err, &rateLimitedErr):
+		return newProblem(fiber.StatusTooManyRequests, CodeRateLimited, rateLimitedErr.Error(),
+			message{key: CodeRateLimited})
+	case errors.As(err, &idempotencyErr):
+		if idempotencyErr.Reason == domain.ReasonIdempotencyReused {
+			return newProblem(fiber.StatusUnprocessableEntity, CodeIdempotencyKeyReused, idempotencyErr.Error(),
+				message{key: CodeIdempotencyKeyReused})
+		}
+		return newProblem(fiber.StatusConflict, CodeIdempotencyKeyInUse, idempotencyErr.Error(),
+			message{key: CodeIdempotencyKeyInUse})
+	case errors.As(err, &notFoundErr):
+		code := lookupCode(notFoundCodes, notFoundErr.Entity, CodeNotFound)
+		return newProblem(fiber.StatusNotFound, code, notFoundErr.Error(),
+			message{key: code, params: []string{notFoundErr.Entity}})
+	case errors.As(err, &conflictErr):
+		code := lookupCode(conflictCodes, conflictErr.Entity+"."+conflictErr.Field, CodeConflict)
+		msg := message{key: code, params: []string{conflictErr.Entity, conflictErr.Field}}
+		problem := newProblem(fiber.StatusConflict, code, conflictErr.Error(), msg)
+		problem.addField(conflictErr.Field, conflictErr.Error(), msg)
+		return problem
+	case errors.As(err, &invalidReferenceErr):
+		code := lookupCode(invalidReferenceCodes, invalidReferenceErr.Entity+"."+invalidReferenceErr.Field, CodeInvalidReference)
+		msg := message{key: code, params: []string{invalidReferenceErr.Entity, invalidReferenceErr.Field}}
+		problem := newProblem(fiber.StatusUnprocessableEntity, code, invalidReferenceErr.Error(), msg)
+		problem.addField(invalidReferenceErr.Field, invalidReferenceErr.Error(), msg)
+		return problem
+	case errors.As(err, &invalidInputErr):
+		msg := message{key: invalidInputErr.Reason}
+		if invalidInputErr.Field != "" {
+			msg = message{key: CodeInvalidInput, params: []string{invalidInputErr.Field, invalidInputErr.Reason}}
+		}
+		problem := newProblem(fiber.StatusBadRequest, CodeInvalidInput, invalidInputErr.Error(), msg)
+		problem.addField(invalidInputErr.Field, invalidInputErr.Reason, message{key: invalidInputErr.Reason})
+		return problem
+	case errors.As(err, &preconditionErr):
+		code := lookupCode(preconditionCodes, preconditionErr.Entity, CodePreconditionFailed)
+		return newProblem(fiber.StatusPreconditionFailed, code, preconditionErr.Error(),
+			message{key: code, params: []string{preconditionErr.Entity}})
+	case errors.As(err, &dependentsErr):
+		code := lookupCode(dependentsCodes, dependentsErr.Entity+"."+dependentsErr.Dependent, CodeHasDependents)
+		problem := newProblem(fiber.StatusConflict, code, dependentsErr.Error(),
+			message{key: code, params: []string{dependentsErr.Entity, dependentsErr.Dependent}})
+		problem.Blocking = dependentsErr.References
+		return problem
+	case errors.As(err, &ruleViolationErr):
+		code := lookupCode(ruleCodes, ruleViolationErr.Rule, CodeUnprocessableEntity)
+		msg := message{key: code}
+		problem := newProblem(fiber.StatusUnprocessableEntity, code, ruleViolationErr.Error(), msg)
+		problem.addField(ruleViolationErr.Field, ruleViolationErr.Error(), msg)
+		return problem
+	default:
+		return newProblem(fiber.StatusInternalServerError, CodeInternal, "Internal server error",
+			message{key: CodeInternal})
+	}
+}
+
+func newProblem(status int, code string, detail string, msg message) Problem {
+	return Problem{
+		Type:    "/problems/" + strings.ReplaceAll(strings.ToLower(code), "_", "-"),
+		Title:   utils.StatusMessage(status),
+		Status:  status,
+		Detail:  detail,
+		Code:    code,
+		message: msg,
+	}
+}
+
+func (p *Problem) addField(field string, text string, msg message) {
+	if field == "" {
+		return
+	}
+	p.Errors = append(p.Errors, FieldError{Field: field, Message: text, message: msg})
+}
+
+// Localize translates the detail and field messages. Texts without an entry
+// in the catalog are kept in English.
+func (p *Problem) Localize(trans ut.Translator) {
+	if trans == nil {
+		return
+	}
+
+	p.Detail = p.message.translate(trans, p.Detail)
+	for i := range p.Errors {
+		p.Errors[i].localize(trans)
+	}
+}
+
+func (f *FieldError) localize(trans ut.Translator) {
+	if f.source == nil {
+		f.Message = f.message.translate(trans, f.Message)
+		return
+	}
+
+	// Translate falls back to the raw validator error when the tag has no
+	// translation, in which case the English message is kept.
+	if text := f.source.Translate(trans); text != f.source.Error() {
+		f.Message = text
+	}
+}
+
+func (m message) translate(trans ut.Translator, fallback string) string {
+	if m.key == "" {
+		return fallback
+	}
+
+	params := make([]string, len(m.params))
+	for i, param := range m.params {
+		params[i] = translate(trans, param, param)
+	}
+	return translate(trans, fallback, m.key, params...)
+}
@@ -0,0 +1,82 @@
+package exception
+
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TranslatorKey is the fiber.Ctx local holding the ut.Translator of the request.
+const TranslatorKey = "translator"
+
+// DefaultLocale is used when the client sends no supported Accept-Language.
+const DefaultLocale = "en"
+
+// Locales lists the supported locales, DefaultLocale first.
+var Locales = []string{"en", "id"}
+
+// NewTranslator registers the validator and error message catalogs of every
+// supported locale.
+func NewTranslator(validate *validator.Validate) (*ut.UniversalTranslator, error) {
+	uni := ut.New(en.New(), en.New(), id.New())
+
+	defaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": enTranslations.RegisterDefaultTranslations,
+		"id": idTranslations.RegisterDefaultTranslations,
+	}
+
+	for _, locale := range Locales {
+		trans, _ := uni.GetTranslator(locale)
+		if err := defaults[locale](validate, trans); err != nil {
+			return nil, err
+		}
+		for tag, text := range validationMessages[locale] {
+			if err := registerValidationMessage(validate, trans, tag, text); err != nil {
+				return nil, err
+			}
+		}
+		for key, text := range messages[locale] {
+			if err := trans.Add(key, text, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return uni, nil
+}
+
+func registerValidationMessage(validate *validator.Validate, trans ut.Translator, tag string, text string) error {
+	return validate.RegisterTranslation(tag, trans,
+		func(trans ut.Translator) error {
+			return trans.Add(tag, text, true)
+		},
+		func(trans ut.Translator, fieldErr validator.FieldError) string {
+			message, err := trans.T(tag, fieldErr.Field(), fieldErr.Param())
+			if err != nil {
+				return fieldErr.Error()
+			}
+			return message
+		})
+}
+
+// translatorOf returns the translator stored on the request, if any.
+func translatorOf(c *fiber.Ctx) ut.Translator {
+	trans, _ := c.Locals(TranslatorKey).(ut.Translator)
+	return trans
+}
+
+// translate looks key up in trans, returning fallback when it is unknown.
+func translate(trans ut.Translator, fallback string, key string, params ...string) string {
+	if trans == nil {
+		return fallback
+	}
+	message, err := trans.T(key, params...)
+	if err != nil {
+		return fallback
+	}
+	return message
+}
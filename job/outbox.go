@@ -0,0 +1,78 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/service"
+)
+
+// outboxCleanupInterval is how often published events older than the
+// retention are removed.
+const outboxCleanupInterval = time.Hour
+
+type OutboxJob interface {
+	Start(ctx context.Context)
+}
+
+type outboxJob struct {
+	outboxService service.OutboxService
+	config        config.OutboxConfig
+	lastCleanup   time.Time
+}
+
+func NewOutboxJob(outboxService service.OutboxService, config config.OutboxConfig) OutboxJob {
+	return &outboxJob{
+		outboxService: outboxService,
+		config:        config,
+	}
+}
+
+// Start relays pending events on every interval until ctx is done. It
+// returns immediately, the work runs in its own goroutine.
+func (j *outboxJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// run publishes batches until the outbox is drained or a batch comes back
+// short, which also happens while another instance holds the relay lock.
+func (j *outboxJob) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := j.outboxService.Relay(ctx)
+		if err != nil {
+			logger.Errorw("Failed to relay outbox events", "error", err)
+			return
+		}
+		if published < j.config.BatchSize {
+			break
+		}
+	}
+
+	if time.Since(j.lastCleanup) < outboxCleanupInterval {
+		return
+	}
+	j.lastCleanup = time.Now()
+	before := time.Now().Add(-j.config.Retention)
+	deleted, err := j.outboxService.Cleanup(ctx, before)
+	if err != nil {
+		logger.Errorw("Failed to clean up outbox", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger.Infow("Cleaned up outbox", "events", deleted, "before", before)
+	}
+}
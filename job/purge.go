@@ -0,0 +1,59 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/service"
+	"test-backend-altech/utils"
+)
+
+var logger = utils.NewLogger()
+
+type PurgeJob interface {
+	Start(ctx context.Context)
+}
+
+type purgeJob struct {
+	trashService service.TrashService
+	config       config.TrashConfig
+}
+
+func NewPurgeJob(trashService service.TrashService, config config.TrashConfig) PurgeJob {
+	return &purgeJob{
+		trashService: trashService,
+		config:       config,
+	}
+}
+
+// Start purges the trash once and then on every interval until ctx is done.
+// It returns immediately, the work runs in its own goroutine.
+func (j *purgeJob) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			j.run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *purgeJob) run(ctx context.Context) {
+	before := time.Now().Add(-j.config.Retention)
+	purged, err := j.trashService.Purge(ctx, before)
+	if err != nil {
+		logger.Errorw("Failed to purge trash", "error", err)
+		return
+	}
+	if purged.Authors > 0 || purged.Books > 0 {
+		logger.Infow("Purged trash", "authors", purged.Authors, "books", purged.Books, "before", before)
+	}
+}
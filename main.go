@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"test-backend-altech/utils"
@@ -8,14 +9,17 @@ import (
 
 	"test-backend-altech/config"
 	"test-backend-altech/controller"
+	"test-backend-altech/exception"
+	"test-backend-altech/job"
+	"test-backend-altech/middleware"
 	"test-backend-altech/repository"
 	"test-backend-altech/repository/query"
 	"test-backend-altech/service"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -28,37 +32,103 @@ func main() {
 	serverConfig := config.NewServerConfig()
 	db := config.NewPostgresDatabase()
 	store := repository.NewStore(db)
-	validate := validator.New()
+	uow := repository.NewUnitOfWork(store)
+	validate := config.NewValidator()
+	translator, err := exception.NewTranslator(validate)
+	if err != nil {
+		log.Fatalf("Failed to register translations: %v", err)
+	}
+	cache, errCache := config.NewRedisCache(&config.RedisConfig{
+		Host: os.Getenv("REDIS_HOST"),
+	})
 	authorQuery := query.NewAuthor()
 	authorRepository := repository.NewAuthorRepository(store, authorQuery)
-	authorMemberService := service.NewAuthorService(authorRepository)
-	authorController := controller.NewAuthorController(validate, authorMemberService)
-
 	bookQuery := query.NewBook()
 	bookRepository := repository.NewBookRepository(store, bookQuery)
+	auditRepository := repository.NewAuditRepository(store, query.NewAudit())
+	outboxRepository := repository.NewOutboxRepository(store, query.NewOutbox())
 
-	cache, errCache := config.NewRedisCache(&config.RedisConfig{
-		Host: os.Getenv("REDIS_HOST"),
-	})
-	bookMemberService := service.NewBookService(bookRepository, cache)
-	bookController := controller.NewBookController(validate, bookMemberService, cache)
+	authorMemberService := service.NewAuthorService(authorRepository, bookRepository, auditRepository, outboxRepository, uow, validate, cache)
+	cacheControl := config.NewCacheControlConfig()
+	idempotencyConfig := config.NewIdempotencyConfig()
+	authorController := controller.NewAuthorController(validate, authorMemberService, cache, cacheControl, idempotencyConfig)
+
+	bookMemberService := service.NewBookService(bookRepository, authorRepository, auditRepository, outboxRepository, uow, validate, cache)
+	bookController := controller.NewBookController(validate, bookMemberService, cache, cacheControl, idempotencyConfig)
+	metricsController := controller.NewMetricsController(store)
+	trashService := service.NewTrashService(authorRepository, bookRepository, uow)
+	trashController := controller.NewTrashController(trashService)
+	auditService := service.NewAuditService(auditRepository)
+	auditController := controller.NewAuditController(validate, auditService)
+
+	authConfig := config.NewAuthConfig()
+	userRepository := repository.NewUserRepository(store, query.NewUser())
+	roleRepository := repository.NewRoleRepository(store, query.NewRole())
+	roleService := service.NewRoleService(roleRepository, userRepository, uow)
+	userService := service.NewUserService(userRepository, roleRepository, uow)
+	tokenService := service.NewTokenService(authConfig, cache)
+	apiKeyRepository := repository.NewApiKeyRepository(store, query.NewApiKey())
+	apiKeyService := service.NewApiKeyService(apiKeyRepository, userRepository, roleRepository)
+	oidcConfig := config.NewOIDCConfig()
+	oidcService := service.NewOIDCService(oidcConfig, cache, userRepository, roleRepository, uow, tokenService)
+	authService := service.NewAuthService(userRepository, tokenService)
+	authController := controller.NewAuthController(validate, authService, userService)
+	userController := controller.NewUserController(validate, userService)
+	adminController := controller.NewAdminController(validate, roleService)
+	apiKeyController := controller.NewApiKeyController(validate, apiKeyService)
+	oidcController := controller.NewOIDCController(validate, oidcService)
+	rateLimiter := service.NewRateLimiter(cache)
 
 	if errCache != nil {
 		log.Fatalf("Failed to connect to cache: %v", errCache)
 	}
 
-	app := fiber.New(fiber.Config{BodyLimit: 10 * 1024 * 1024})
+	outboxConfig := config.NewOutboxConfig()
+	eventPublisher, err := service.NewEventPublisher(outboxConfig, cache)
+	if err != nil {
+		log.Fatalf("Failed to create the event publisher: %v", err)
+	}
+	outboxService := service.NewOutboxService(outboxRepository, uow, eventPublisher, outboxConfig)
+
+	app := fiber.New(fiber.Config{
+		BodyLimit:    10 * 1024 * 1024,
+		ErrorHandler: exception.ErrorHandler,
+	})
+	app.Use(requestid.New())
+	app.Use(middleware.Localize(translator))
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
 		AllowMethods:     "*",
 		AllowHeaders:     "*",
+		ExposeHeaders:    "ETag, RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Idempotent-Replayed",
 		AllowCredentials: false,
 	}))
+	rateLimitConfig := config.NewRateLimitConfig()
+	app.Use(middleware.RateLimitAuthFailures(rateLimiter, rateLimitConfig))
+	app.Use(middleware.Authenticate(tokenService, apiKeyService, oidcService, roleService))
+	app.Use(middleware.RateLimit(rateLimiter, rateLimitConfig))
 
 	authorController.Route(app)
 	bookController.Route(app)
-	err := app.Listen(serverConfig.Host)
+	metricsController.Route(app)
+	trashController.Route(app)
+	authController.Route(app)
+	userController.Route(app)
+	adminController.Route(app)
+	apiKeyController.Route(app)
+	auditController.Route(app)
+	if oidcConfig.Enabled() {
+		oidcController.Route(app)
+	}
+
+	if err := userService.EnsureInitialUser(context.Background(), authConfig.AdminEmail, authConfig.AdminPassword); err != nil {
+		log.Fatalf("Failed to create the initial user: %v", err)
+	}
+
+	job.NewPurgeJob(trashService, config.NewTrashConfig()).Start(context.Background())
+	job.NewOutboxJob(outboxService, outboxConfig).Start(context.Background())
+	err = app.Listen(serverConfig.Host)
 	if err != nil {
 		log.Fatal(err)
 		logger.Error(err)
@@ -0,0 +1,19 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// CacheControl sets the Cache-Control header of successful responses of a
+// route. An empty policy leaves the header unset, and so does a handler that
+// set the header itself.
+func CacheControl(policy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		status := c.Response().StatusCode()
+		if policy != "" && len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 && (status == fiber.StatusOK || status == fiber.StatusNotModified) {
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	idempotencyMaxKeyLength   = 255
+	idempotencyStateInFlight  = "in_flight"
+	idempotencyStateCompleted = "completed"
+)
+
+// idempotencyHeaders are the response headers replayed with the body.
+var idempotencyHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag, fiber.HeaderLocation}
+
+// releaseIdempotencyScript deletes KEYS[1] only while it still holds the
+// marker ARGV[1], so a request whose marker expired can not release the key
+// of a newer request.
+var releaseIdempotencyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// storeIdempotencyScript replaces the marker ARGV[1] in KEYS[1] with the
+// response ARGV[2] for ARGV[3] milliseconds, and leaves KEYS[1] alone once it
+// holds anything else.
+var storeIdempotencyScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// idempotencyRecord is what is stored in the cache for a key: first a
+// marker while the request runs, then its response. The random token makes
+// each marker unique to the request that set it.
+type idempotencyRecord struct {
+	State   string            `json:"state"`
+	Hash    string            `json:"hash"`
+	Token   string            `json:"token,omitempty"`
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+// Idempotency makes a route safe to retry with an Idempotency-Key header.
+// The first successful response for a key is stored for cfg.TTL and
+// replayed for later requests of the same caller with the same key and body.
+// The same key with another body is answered with 422, while the first
+// request is still running with 409. Failed requests are not stored, so they
+// can be retried with the same key. Requests without the header are not
+// affected. It must run after Authenticate, keys are scoped to the caller.
+func Idempotency(cache config.Cache, cfg config.IdempotencyConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > idempotencyMaxKeyLength {
+			return &domain.InvalidInputError{Field: HeaderIdempotencyKey, Reason: "is too long"}
+		}
+
+		sum := sha256.Sum256(c.Body())
+		hash := hex.EncodeToString(sum[:])
+		cacheKey := "idempotency:" + callerKey(c) + ":" + c.Method() + ":" + c.Path() + ":" + key
+
+		token, err := idempotencyToken()
+		if err != nil {
+			return err
+		}
+		marker, err := json.Marshal(idempotencyRecord{State: idempotencyStateInFlight, Hash: hash, Token: token})
+		if err != nil {
+			return err
+		}
+		acquired, err := cache.SetNX(c.Context(), cacheKey, marker, cfg.InFlightTTL)
+		if err != nil {
+			logger.Warnw("Idempotency check failed", "error", err)
+			return c.Next()
+		}
+		if !acquired {
+			body, err := cache.Get(c.Context(), cacheKey)
+			if !errors.Is(err, config.ErrCacheMiss) {
+				if err != nil {
+					return err
+				}
+				return replay(c, body, hash)
+			}
+			// The first request failed and released the key in the meantime,
+			// so this one may run instead.
+			acquired, err = cache.SetNX(c.Context(), cacheKey, marker, cfg.InFlightTTL)
+			if err != nil {
+				return err
+			}
+			if !acquired {
+				return &domain.IdempotencyError{Reason: domain.ReasonIdempotencyInFlight}
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			release(c.Context(), cache, cacheKey, marker)
+			return err
+		}
+		status := c.Response().StatusCode()
+		if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			release(c.Context(), cache, cacheKey, marker)
+			return nil
+		}
+
+		record := idempotencyRecord{
+			State:   idempotencyStateCompleted,
+			Hash:    hash,
+			Status:  status,
+			Headers: map[string]string{},
+			Body:    append([]byte(nil), c.Response().Body()...),
+		}
+		for _, header := range idempotencyHeaders {
+			if value := c.GetRespHeader(header); value != "" {
+				record.Headers[header] = value
+			}
+		}
+		body, err := json.Marshal(record)
+		if err == nil {
+			var stored interface{}
+			stored, err = cache.Eval(c.Context(), storeIdempotencyScript, []string{cacheKey}, marker, body, cfg.TTL.Milliseconds())
+			if err == nil && stored == int64(0) {
+				logger.Warnw("Idempotency key expired before the response was stored", "key", key)
+			}
+		}
+		if err != nil {
+			logger.Warnw("Failed to store idempotent response", "error", err)
+		}
+		return nil
+	}
+}
+
+// replay answers a request whose key is already taken with the stored
+// record body.
+func replay(c *fiber.Ctx, body []byte, hash string) error {
+	var record idempotencyRecord
+	if err := json.Unmarshal(body, &record); err != nil {
+		return err
+	}
+	if record.Hash != hash {
+		return &domain.IdempotencyError{Reason: domain.ReasonIdempotencyReused}
+	}
+	if record.State != idempotencyStateCompleted {
+		return &domain.IdempotencyError{Reason: domain.ReasonIdempotencyInFlight}
+	}
+
+	for header, value := range record.Headers {
+		c.Set(header, value)
+	}
+	c.Set(HeaderIdempotentReplayed, "true")
+	return c.Status(record.Status).Send(record.Body)
+}
+
+// release forgets a key whose request failed, as long as it still holds the
+// marker of that request.
+func release(ctx context.Context, cache config.Cache, cacheKey string, marker []byte) {
+	if _, err := cache.Eval(ctx, releaseIdempotencyScript, []string{cacheKey}, marker); err != nil {
+		logger.Warnw("Failed to release idempotency key", "error", err)
+	}
+}
+
+// idempotencyToken returns a random token for an in-flight marker.
+func idempotencyToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
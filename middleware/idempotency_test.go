@@ -0,0 +1,224 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/exception"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var testIdempotencyConfig = config.IdempotencyConfig{TTL: time.Hour, InFlightTTL: time.Minute}
+
+// newIdempotencyTestApp serves POST /books with handle behind Idempotency.
+func newIdempotencyTestApp(cache config.Cache, handle fiber.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: exception.ErrorHandler})
+	app.Post("/books", Idempotency(cache, testIdempotencyConfig), handle)
+	return app
+}
+
+type idempotencyTestResponse struct {
+	status   int
+	body     string
+	location string
+	replayed string
+}
+
+func postIdempotent(t *testing.T, app *fiber.App, key string, body string) idempotencyTestResponse {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/books", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(HeaderIdempotencyKey, key)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("POST /books: %v", err)
+	}
+	return readIdempotencyTestResponse(t, res)
+}
+
+func readIdempotencyTestResponse(t *testing.T, res *http.Response) idempotencyTestResponse {
+	t.Helper()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return idempotencyTestResponse{
+		status:   res.StatusCode,
+		body:     string(body),
+		location: res.Header.Get(fiber.HeaderLocation),
+		replayed: res.Header.Get(HeaderIdempotentReplayed),
+	}
+}
+
+// bookHandler counts its calls and answers with the number of the call, so
+// replays can be told apart from requests that ran again. Bodies containing
+// "fail" are answered with 500. With hold set the first call closes started
+// and waits for finish to be closed; with failFirst it then fails.
+type bookHandler struct {
+	calls     atomic.Int32
+	hold      bool
+	failFirst bool
+	started   chan struct{}
+	finish    chan struct{}
+}
+
+func (h *bookHandler) handle(c *fiber.Ctx) error {
+	call := h.calls.Add(1)
+	if call == 1 && h.hold {
+		close(h.started)
+		<-h.finish
+	}
+	if call == 1 && h.failFirst || strings.Contains(string(c.Body()), "fail") {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+	c.Location("/books/" + strconv.Itoa(int(call)))
+	return c.Status(fiber.StatusCreated).SendString("book " + strconv.Itoa(int(call)))
+}
+
+func TestIdempotency(t *testing.T) {
+	type step struct {
+		key      string
+		body     string
+		status   int
+		response string
+		replayed bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		calls int32
+	}{
+		{
+			name: "replays the first response",
+			steps: []step{
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 1"},
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 1", replayed: true},
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 1", replayed: true},
+			},
+			calls: 1,
+		},
+		{
+			name: "rejects the key with another body",
+			steps: []step{
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 1"},
+				{key: "a", body: `{"title":"B"}`, status: fiber.StatusUnprocessableEntity},
+			},
+			calls: 1,
+		},
+		{
+			name: "keys are independent",
+			steps: []step{
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 1"},
+				{key: "b", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 2"},
+			},
+			calls: 2,
+		},
+		{
+			name: "retakes the key of a failed request",
+			steps: []step{
+				{key: "a", body: `{"title":"fail"}`, status: fiber.StatusInternalServerError},
+				{key: "a", body: `{"title":"fail"}`, status: fiber.StatusInternalServerError},
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 3"},
+				{key: "a", body: `{"title":"A"}`, status: fiber.StatusCreated, response: "book 3", replayed: true},
+			},
+			calls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cache := newTestRedis(t)
+			handler := &bookHandler{}
+			app := newIdempotencyTestApp(cache, handler.handle)
+
+			for i, step := range tt.steps {
+				got := postIdempotent(t, app, step.key, step.body)
+				if got.status != step.status {
+					t.Fatalf("step %d: status = %d, want %d (%s)", i, got.status, step.status, got.body)
+				}
+				if step.response != "" && got.body != step.response {
+					t.Errorf("step %d: body = %q, want %q", i, got.body, step.response)
+				}
+				if step.response != "" && got.location != "/books/"+strings.TrimPrefix(step.response, "book ") {
+					t.Errorf("step %d: Location = %q, want the one of %q", i, got.location, step.response)
+				}
+				if (got.replayed == "true") != step.replayed {
+					t.Errorf("step %d: %s = %q, want replayed %v", i, HeaderIdempotentReplayed, got.replayed, step.replayed)
+				}
+			}
+			if got := handler.calls.Load(); got != tt.calls {
+				t.Errorf("handler ran %d times, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	tests := []struct {
+		name string
+		// retry is the body sent while the first request, with body
+		// {"title":"A"}, still runs.
+		retry string
+		// expire lets the marker of the first request expire before the
+		// retry, so the retry takes the key over.
+		expire bool
+		// failFirst makes the first request fail once it is let go.
+		failFirst   bool
+		retryStatus int
+		// final is the response replayed once both requests are done.
+		final string
+	}{
+		{name: "a retry waits for the running request", retry: `{"title":"A"}`, retryStatus: fiber.StatusConflict, final: "book 1"},
+		{name: "a retry with another body is rejected", retry: `{"title":"B"}`, retryStatus: fiber.StatusUnprocessableEntity, final: "book 1"},
+		{name: "an expired request does not overwrite the retry", retry: `{"title":"A"}`, expire: true, retryStatus: fiber.StatusCreated, final: "book 2"},
+		{name: "an expired request does not release the retry", retry: `{"title":"A"}`, expire: true, failFirst: true, retryStatus: fiber.StatusCreated, final: "book 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, cache := newTestRedis(t)
+			handler := &bookHandler{
+				hold:      true,
+				failFirst: tt.failFirst,
+				started:   make(chan struct{}),
+				finish:    make(chan struct{}),
+			}
+			app := newIdempotencyTestApp(cache, handler.handle)
+
+			first := make(chan idempotencyTestResponse, 1)
+			go func() {
+				first <- postIdempotent(t, app, "a", `{"title":"A"}`)
+			}()
+			select {
+			case <-handler.started:
+			case <-time.After(5 * time.Second):
+				t.Fatal("first request did not start")
+			}
+			if tt.expire {
+				server.FastForward(testIdempotencyConfig.InFlightTTL + time.Second)
+			}
+
+			retry := postIdempotent(t, app, "a", tt.retry)
+			if retry.status != tt.retryStatus {
+				t.Fatalf("retry: status = %d, want %d (%s)", retry.status, tt.retryStatus, retry.body)
+			}
+
+			close(handler.finish)
+			<-first
+
+			final := postIdempotent(t, app, "a", `{"title":"A"}`)
+			if final.body != tt.final || final.replayed != "true" {
+				t.Errorf("final: body = %q, replayed %q, want %q replayed", final.body, final.replayed, tt.final)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"test-backend-altech/exception"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Localize picks the locale of the request from Accept-Language and stores
+// its translator so error messages are rendered in that language.
+func Localize(uni *ut.UniversalTranslator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := c.AcceptsLanguages(exception.Locales...)
+		if locale == "" {
+			locale = exception.DefaultLocale
+		}
+
+		trans, _ := uni.GetTranslator(locale)
+		c.Locals(exception.TranslatorKey, trans)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+	"test-backend-altech/service"
+	"test-backend-altech/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var logger = utils.NewLogger()
+
+// RateLimit limits requests per caller with the policy cfg assigns to the
+// route. Callers are told apart by API key, then user, then client IP, so it
+// must run after Authenticate. Every response carries RateLimit-* headers,
+// requests over the limit are answered with 429 and Retry-After. When Redis
+// is unavailable requests are let through rather than failing the API.
+func RateLimit(limiter service.RateLimiter, cfg config.RateLimitConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !cfg.Enabled {
+			return c.Next()
+		}
+
+		policy := cfg.Policy(c.Method(), c.Path())
+		result, err := limiter.Allow(c.Context(), policy, rateLimitSubject(c, policy))
+		if err != nil {
+			logger.Warnw("Rate limit check failed", "policy", policy.Name, "error", err)
+			return c.Next()
+		}
+
+		if err := limitResult(c, policy, result); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// RateLimitAuthFailures counts requests whose credentials Authenticate
+// rejects against the auth policy of the client IP, and answers requests
+// with credentials from an IP that used it up with 429 before they are
+// checked, so keys and tokens can not be guessed faster than passwords. It
+// must run before Authenticate.
+func RateLimitAuthFailures(limiter service.RateLimiter, cfg config.RateLimitConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !cfg.Enabled || c.Get(fiber.HeaderAuthorization) == "" {
+			return c.Next()
+		}
+
+		policy := cfg.Policies[config.RateLimitAuth]
+		subject := "ip:" + c.IP()
+		result, err := limiter.Check(c.Context(), policy, subject)
+		if err != nil {
+			logger.Warnw("Rate limit check failed", "policy", policy.Name, "error", err)
+			return c.Next()
+		}
+		if !result.Allowed {
+			return limitResult(c, policy, result)
+		}
+
+		err = c.Next()
+		var unauthenticatedErr *domain.UnauthenticatedError
+		if _, authenticated := c.Locals(domain.PrincipalKey).(domain.Principal); authenticated || !errors.As(err, &unauthenticatedErr) {
+			return err
+		}
+		if _, limitErr := limiter.Allow(c.Context(), policy, subject); limitErr != nil {
+			logger.Warnw("Rate limit check failed", "policy", policy.Name, "error", limitErr)
+		}
+		return err
+	}
+}
+
+// limitResult sets the RateLimit-* headers of result and rejects the request
+// when it is over the limit.
+func limitResult(c *fiber.Ctx, policy config.RateLimitPolicy, result service.RateLimitResult) error {
+	c.Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(seconds(policy.Window)))
+	c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	if !result.Allowed {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds(result.RetryAfter)))
+		return &domain.RateLimitedError{Policy: policy.Name}
+	}
+	return nil
+}
+
+func rateLimitSubject(c *fiber.Ctx, policy config.RateLimitPolicy) string {
+	if policy.ByIP {
+		return "ip:" + c.IP()
+	}
+	return callerKey(c)
+}
+
+// seconds rounds d up to whole seconds, as the headers expect.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
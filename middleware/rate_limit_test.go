@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/exception"
+	"test-backend-altech/service"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestRedis starts a miniredis, whose clock only moves when the test sets
+// it, and returns it together with a cache connected to it.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, config.Cache) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+
+	t.Setenv("REDIS_PASSWORD", "")
+	cache, err := config.NewRedisCache(&config.RedisConfig{Host: server.Addr()})
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	return server, cache
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	server, cache := newTestRedis(t)
+	cfg := config.RateLimitConfig{
+		Enabled: true,
+		Policies: map[string]config.RateLimitPolicy{
+			config.RateLimitDefault: {Name: config.RateLimitDefault, Limit: 3, Window: 3 * time.Second},
+		},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: exception.ErrorHandler})
+	app.Use(RateLimit(service.NewRateLimiter(cache), cfg))
+	app.Get("/books", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	// Reset and Retry-After are rounded up to whole seconds.
+	tests := []struct {
+		name       string
+		after      time.Duration
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{name: "first request", status: fiber.StatusOK, remaining: "2", reset: "1"},
+		{name: "second request", status: fiber.StatusOK, remaining: "1", reset: "2"},
+		{name: "last token", status: fiber.StatusOK, remaining: "0", reset: "3"},
+		{name: "over the limit", status: fiber.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{name: "partly refilled", after: 250 * time.Millisecond, status: fiber.StatusTooManyRequests, remaining: "0", reset: "3", retryAfter: "1"},
+		{name: "refilled one token", after: 750 * time.Millisecond, status: fiber.StatusOK, remaining: "0", reset: "3"},
+		{name: "refilled completely", after: time.Hour, status: fiber.StatusOK, remaining: "2", reset: "1"},
+	}
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		now = now.Add(tt.after)
+		server.SetTime(now)
+
+		res, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/books", nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if res.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, res.StatusCode, tt.status)
+		}
+		want := map[string]string{
+			"RateLimit-Policy":     "3;w=3",
+			"RateLimit-Limit":      "3",
+			"RateLimit-Remaining":  tt.remaining,
+			"RateLimit-Reset":      tt.reset,
+			fiber.HeaderRetryAfter: tt.retryAfter,
+		}
+		for header, value := range want {
+			if got := res.Header.Get(header); got != value {
+				t.Errorf("%s: %s = %q, want %q", tt.name, header, got, value)
+			}
+		}
+	}
+}
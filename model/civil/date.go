@@ -0,0 +1,150 @@
+package civil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ISOLayout is the format a Date is always written in.
+const ISOLayout = "2006-01-02"
+
+// inputLayouts are the formats accepted when parsing a Date. Day-first
+// layouts are tried before month-first ones, as used in Indonesia.
+var inputLayouts = []string{
+	ISOLayout,
+	"2006/01/02",
+	"02-01-2006",
+	"02/01/2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+}
+
+// Date is a calendar date without a time of day or location, stored in DATE
+// columns. The zero Date stands for a missing value and is written as null.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// ParseError is returned when a value does not match any accepted date format.
+type ParseError struct {
+	Value string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%q is not a valid date, use the YYYY-MM-DD format", e.Value)
+}
+
+// DateOf returns the date of t in its own location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// Today returns the current date in UTC.
+func Today() Date {
+	return DateOf(time.Now().UTC())
+}
+
+// ParseDate parses s in any of the accepted input formats.
+func ParseDate(s string) (Date, error) {
+	value := strings.TrimSpace(s)
+	for _, layout := range inputLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return DateOf(t), nil
+		}
+	}
+	return Date{}, &ParseError{Value: s}
+}
+
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// String returns the date in ISO-8601 format, or an empty string for the zero Date.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Time().Format(ISOLayout)
+}
+
+// Time returns midnight UTC of the date.
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+func (d Date) Before(other Date) bool {
+	return d.Time().Before(other.Time())
+}
+
+func (d Date) After(other Date) bool {
+	return d.Time().After(other.Time())
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return &ParseError{Value: string(data)}
+	}
+	if strings.TrimSpace(s) == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// ScanDate implements pgtype.DateScanner.
+func (d *Date) ScanDate(v pgtype.Date) error {
+	if !v.Valid {
+		*d = Date{}
+		return nil
+	}
+	*d = DateOf(v.Time)
+	return nil
+}
+
+// DateValue implements pgtype.DateValuer.
+func (d Date) DateValue() (pgtype.Date, error) {
+	if d.IsZero() {
+		return pgtype.Date{}, nil
+	}
+	return pgtype.Date{Time: d.Time(), Valid: true}, nil
+}
+
+// Value implements driver.Valuer for parameters whose type pgx can not infer.
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.String(), nil
+}
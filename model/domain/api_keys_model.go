@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"slices"
+	"test-backend-altech/model/web/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key can be limited to. Reads are public, so the read scopes
+// only grant reading past versions; they let a key state what it is used for.
+const (
+	ScopeAuthorsRead  = "authors:read"
+	ScopeAuthorsWrite = "authors:write"
+	ScopeBooksRead    = "books:read"
+	ScopeBooksWrite   = "books:write"
+	ScopeTrashRead    = "trash:read"
+)
+
+// scopePermissions lists the permissions each scope allows. A key holds the
+// permissions of its scopes that its owner also holds.
+var scopePermissions = map[string][]string{
+	ScopeAuthorsRead:  {PermissionHistoryRead},
+	ScopeAuthorsWrite: {PermissionAuthorsCreate, PermissionAuthorsUpdate, PermissionAuthorsUpdateAny, PermissionAuthorsDelete, PermissionAuthorsMerge},
+	ScopeBooksRead:    {PermissionHistoryRead},
+	ScopeBooksWrite:   {PermissionBooksCreate, PermissionBooksUpdate, PermissionBooksUpdateAny, PermissionBooksDelete},
+	ScopeTrashRead:    {PermissionTrashRead},
+}
+
+// ScopePermissions returns the permissions of owner that scopes allow.
+func ScopePermissions(scopes []string, owner []string) []string {
+	var permissions []string
+	for _, scope := range scopes {
+		for _, permission := range scopePermissions[scope] {
+			if slices.Contains(owner, permission) && !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
+
+type ApiKey struct {
+	Id         string
+	UserId     string
+	Name       string
+	Prefix     string
+	SecretHash string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (key *ApiKey) GenerateID() {
+	uuid := uuid.New().String()
+	key.Id = uuid
+}
+
+func (key *ApiKey) ToApiKeyResponse() response.ApiKeyResponse {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return response.ApiKeyResponse{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"test-backend-altech/model/web/response"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditMerge   = "merge"
+)
+
+// Entities recorded in the audit log.
+const (
+	AuditEntityAuthor = "author"
+	AuditEntityBook   = "book"
+)
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditEntry struct {
+	Id        int64
+	ActorId   *string
+	ApiKeyId  *string
+	RequestId string
+	Action    string
+	Entity    string
+	EntityId  string
+	Changes   map[string]AuditChange
+	Details   map[string]interface{}
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit entries, empty fields match everything.
+type AuditFilter struct {
+	Entity   string
+	EntityId string
+	ActorId  string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+func (entry *AuditEntry) ToAuditEntryResponse() response.AuditEntryResponse {
+	changes := make(map[string]response.AuditChangeResponse, len(entry.Changes))
+	for field, change := range entry.Changes {
+		changes[field] = response.AuditChangeResponse{Before: change.Before, After: change.After}
+	}
+	details := entry.Details
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	return response.AuditEntryResponse{
+		Id:        entry.Id,
+		ActorId:   entry.ActorId,
+		ApiKeyId:  entry.ApiKeyId,
+		RequestId: entry.RequestId,
+		Action:    entry.Action,
+		Entity:    entry.Entity,
+		EntityId:  entry.EntityId,
+		Changes:   changes,
+		Details:   details,
+		CreatedAt: entry.CreatedAt,
+	}
+}
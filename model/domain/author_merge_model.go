@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"test-backend-altech/model/web/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorMerge records that duplicate authors were merged into a survivor.
+type AuthorMerge struct {
+	Id           string
+	SurvivorId   string
+	DuplicateIds []string
+	Precedence   string
+	BooksMoved   int64
+	MergedAt     time.Time
+}
+
+func (merge *AuthorMerge) GenerateID() {
+	merge.Id = uuid.New().String()
+}
+
+// ToAuthorMergeResponse describes the merge with survivor as it was after
+// the merge.
+func (merge *AuthorMerge) ToAuthorMergeResponse(survivor Author) response.AuthorMergeResponse {
+	return response.AuthorMergeResponse{
+		Id:         merge.Id,
+		Author:     survivor.ToAuthorResponse(),
+		Duplicates: merge.DuplicateIds,
+		Precedence: merge.Precedence,
+		BooksMoved: merge.BooksMoved,
+		MergedAt:   merge.MergedAt,
+	}
+}
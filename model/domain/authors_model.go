@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"test-backend-altech/model/civil"
 	"test-backend-altech/model/web/response"
 
 	"time"
@@ -12,8 +13,13 @@ type Author struct {
 	Id        string
 	Name      string
 	Bio       string
-	BirthDate string
+	BirthDate civil.Date
+	Version   int
 	CreatedAt time.Time
+	UpdatedAt time.Time
+	CreatedBy *string
+	UpdatedBy *string
+	DeletedAt *time.Time
 }
 
 func (author *Author) GenerateID() {
@@ -27,15 +33,19 @@ func (j *Author) ToAuthorResponse() response.AuthorResponse {
 		Name:      j.Name,
 		Bio:       j.Bio,
 		BirthDate: j.BirthDate,
+		CreatedAt: j.CreatedAt,
+		UpdatedAt: j.UpdatedAt,
+		CreatedBy: j.CreatedBy,
+		UpdatedBy: j.UpdatedBy,
+		DeletedAt: j.DeletedAt,
+		Version:   j.Version,
 	}
 }
 
-type ValidateAuthorName struct {
-	Name string `json:"name"`
-}
-
 type UpdateAuthor struct {
-	Name      string `json:"name"`
-	Bio       string `json:"bio"`
-	BirthDate string `json:"birth_date"`
+	Name      string     `json:"name"`
+	Bio       string     `json:"bio"`
+	BirthDate civil.Date `json:"birth_date"`
+	// Version is the version the update expects, 0 skips the check.
+	Version int `json:"-"`
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"test-backend-altech/model/civil"
 	"test-backend-altech/model/web/response"
 	"time"
 
@@ -11,9 +12,14 @@ type Book struct {
 	Id          string
 	Title       string
 	Description string
-	PublishDate string
+	PublishDate civil.Date
 	AuthorId    string
+	Version     int
 	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   *string
+	UpdatedBy   *string
+	DeletedAt   *time.Time
 }
 
 func (book *Book) GenerateID() {
@@ -28,16 +34,20 @@ func (book *Book) ToBookResponse() response.BookResponse {
 		Description: book.Description,
 		PublishDate: book.PublishDate,
 		AuthorId:    book.AuthorId,
+		CreatedAt:   book.CreatedAt,
+		UpdatedAt:   book.UpdatedAt,
+		CreatedBy:   book.CreatedBy,
+		UpdatedBy:   book.UpdatedBy,
+		DeletedAt:   book.DeletedAt,
+		Version:     book.Version,
 	}
 }
 
-type ValidateBookTitle struct {
-	Title string `json:"name"`
-}
-
 type UpdateBook struct {
-	Title       string `json:"title"`
-	PublishDate string `json:"publish_date"`
-	Description string `json:"description"`
-	AuthorId    string `json:"author_id"`
+	Title       string     `json:"title"`
+	PublishDate civil.Date `json:"publish_date"`
+	Description string     `json:"description"`
+	AuthorId    string     `json:"author_id"`
+	// Version is the version the update expects, 0 skips the check.
+	Version int `json:"-"`
 }
@@ -0,0 +1,4 @@
+package domain
+
+// Changes maps the columns changed by a partial update to their new values.
+type Changes map[string]interface{}
@@ -0,0 +1,209 @@
+package domain
+
+import "fmt"
+
+const (
+	EntityAuthor      = "Author"
+	EntityBook        = "Book"
+	EntityUser        = "User"
+	EntityRole        = "Role"
+	EntityApiKey      = "ApiKey"
+	EntityAuditEntry  = "AuditEntry"
+	EntityOutboxEvent = "OutboxEvent"
+	// EntityAuthorVersion and EntityBookVersion are recorded versions of an
+	// author or book, kept in the history tables.
+	EntityAuthorVersion = "AuthorVersion"
+	EntityBookVersion   = "BookVersion"
+)
+
+// NotFoundError is returned when the requested entity does not exist.
+type NotFoundError struct {
+	Entity string
+	Err    error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Entity)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// ConflictError is returned when a write would violate a uniqueness rule.
+type ConflictError struct {
+	Entity     string
+	Field      string
+	Constraint string
+	Err        error
+}
+
+func (e *ConflictError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s already exists", e.Entity)
+	}
+	return fmt.Sprintf("%s %s already exists", e.Entity, e.Field)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidReferenceError is returned when a write points to another entity
+// that does not exist, such as a book with an unknown author_id.
+type InvalidReferenceError struct {
+	Entity     string
+	Field      string
+	Constraint string
+	Err        error
+}
+
+func (e *InvalidReferenceError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s references a record that does not exist", e.Entity)
+	}
+	return fmt.Sprintf("%s %s references a record that does not exist", e.Entity, e.Field)
+}
+
+func (e *InvalidReferenceError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidInputError is returned when a value can not be used as given,
+// such as a malformed UUID or date.
+type InvalidInputError struct {
+	Field  string
+	Reason string
+	Err    error
+}
+
+func (e *InvalidInputError) Error() string {
+	if e.Field == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("Field '%s' %s", e.Field, e.Reason)
+}
+
+func (e *InvalidInputError) Unwrap() error {
+	return e.Err
+}
+
+// PreconditionFailedError is returned when a write expected another version
+// of the entity than the one currently stored.
+type PreconditionFailedError struct {
+	Entity string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("%s was modified by another request", e.Entity)
+}
+
+// Why a request could not be authenticated.
+const (
+	ReasonMissingToken       = "missing_token"
+	ReasonInvalidToken       = "invalid_token"
+	ReasonTokenExpired       = "token_expired"
+	ReasonTokenRevoked       = "token_revoked"
+	ReasonInvalidCredentials = "invalid_credentials"
+)
+
+var unauthenticatedMessages = map[string]string{
+	ReasonMissingToken:       "Authentication is required",
+	ReasonInvalidToken:       "Token is invalid",
+	ReasonTokenExpired:       "Token has expired",
+	ReasonTokenRevoked:       "Token has been revoked",
+	ReasonInvalidCredentials: "Invalid email or password",
+}
+
+// UnauthenticatedError is returned when a request carries no valid
+// credentials.
+type UnauthenticatedError struct {
+	Reason string
+	Err    error
+}
+
+func (e *UnauthenticatedError) Error() string {
+	if message, ok := unauthenticatedMessages[e.Reason]; ok {
+		return message
+	}
+	return unauthenticatedMessages[ReasonMissingToken]
+}
+
+func (e *UnauthenticatedError) Unwrap() error {
+	return e.Err
+}
+
+// ForbiddenError is returned when the caller is authenticated but lacks the
+// permission an action needs.
+type ForbiddenError struct {
+	Permission string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("Permission '%s' is required", e.Permission)
+}
+
+// RateLimitedError is returned when a caller made more requests than the
+// rate limit policy of a route allows.
+type RateLimitedError struct {
+	Policy string
+}
+
+func (e *RateLimitedError) Error() string {
+	return "Too many requests"
+}
+
+const (
+	ReasonIdempotencyInFlight = "in_flight"
+	ReasonIdempotencyReused   = "reused"
+)
+
+// IdempotencyError is returned when an Idempotency-Key can not be used: the
+// first request with the key is still running, or the key was used with
+// another body.
+type IdempotencyError struct {
+	Reason string
+}
+
+func (e *IdempotencyError) Error() string {
+	if e.Reason == ReasonIdempotencyReused {
+		return "Idempotency-Key was already used with a different request body"
+	}
+	return "A request with this Idempotency-Key is still in progress"
+}
+
+// Reference identifies a record that blocks a write.
+type Reference struct {
+	Id    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// DependentsError is returned when an entity can not be deleted because
+// records of the Dependent entity still point at it.
+type DependentsError struct {
+	Entity     string
+	Dependent  string
+	References []Reference
+}
+
+func (e *DependentsError) Error() string {
+	return fmt.Sprintf("%s is still referenced by %d %s records", e.Entity, len(e.References), e.Dependent)
+}
+
+const (
+	RuleBirthDateInFuture          = "birth_date_in_future"
+	RulePublishedBeforeAuthorBirth = "published_before_author_birth"
+)
+
+// RuleViolationError is returned when a well formed request breaks a
+// business rule, such as a book published before its author was born.
+type RuleViolationError struct {
+	Entity  string
+	Rule    string
+	Field   string
+	Message string
+}
+
+func (e *RuleViolationError) Error() string {
+	return e.Message
+}
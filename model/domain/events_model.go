@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"test-backend-altech/model/web/response"
+	"time"
+)
+
+// Domain events written to the outbox and published by the relay.
+const (
+	EventAuthorCreated  = "AuthorCreated"
+	EventAuthorUpdated  = "AuthorUpdated"
+	EventAuthorDeleted  = "AuthorDeleted"
+	EventAuthorRestored = "AuthorRestored"
+	EventAuthorsMerged  = "AuthorsMerged"
+	EventBookCreated    = "BookCreated"
+	EventBookUpdated    = "BookUpdated"
+	EventBookDeleted    = "BookDeleted"
+	EventBookRestored   = "BookRestored"
+)
+
+// OutboxEvent is a domain event waiting in, or published from, the outbox.
+// AggregateType uses the same names as the audit log.
+type OutboxEvent struct {
+	Id            int64
+	Type          string
+	AggregateType string
+	AggregateId   string
+	Payload       json.RawMessage
+	ActorId       *string
+	RequestId     string
+	CreatedAt     time.Time
+	Attempts      int
+}
+
+func (event *OutboxEvent) ToEventMessage() response.EventMessage {
+	return response.EventMessage{
+		Id:            event.Id,
+		Type:          event.Type,
+		AggregateType: event.AggregateType,
+		AggregateId:   event.AggregateId,
+		OccurredAt:    event.CreatedAt,
+		ActorId:       event.ActorId,
+		RequestId:     event.RequestId,
+		Data:          event.Payload,
+	}
+}
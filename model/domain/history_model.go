@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"test-backend-altech/model/web/response"
+	"time"
+)
+
+// AuthorVersion is an author as it was between ValidFrom and ValidTo. ValidTo
+// is nil for the current version.
+type AuthorVersion struct {
+	Author    Author
+	ValidFrom time.Time
+	ValidTo   *time.Time
+}
+
+func (version *AuthorVersion) ToAuthorVersionResponse() response.AuthorVersionResponse {
+	return response.AuthorVersionResponse{
+		Version:   version.Author.Version,
+		ValidFrom: version.ValidFrom,
+		ValidTo:   version.ValidTo,
+		Author:    version.Author.ToAuthorResponse(),
+	}
+}
+
+// BookVersion is a book as it was between ValidFrom and ValidTo, with the
+// name its author had at ValidFrom. ValidTo is nil for the current version.
+type BookVersion struct {
+	Book      response.BookResponse
+	ValidFrom time.Time
+	ValidTo   *time.Time
+}
+
+func (version *BookVersion) ToBookVersionResponse() response.BookVersionResponse {
+	return response.BookVersionResponse{
+		Version:   version.Book.Version,
+		ValidFrom: version.ValidFrom,
+		ValidTo:   version.ValidTo,
+		Book:      version.Book,
+	}
+}
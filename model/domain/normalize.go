@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName returns the form author names and book titles are compared
+// in: Unicode NFC, full case folding, trimmed and with inner whitespace
+// collapsed to one space. "Straße" and " STRASSE " normalize alike.
+func NormalizeName(value string) string {
+	folded := cases.Fold().String(norm.NFC.String(value))
+	return strings.Join(strings.Fields(folded), " ")
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "lowercases", value: "Jane Austen", want: "jane austen"},
+		{name: "trims and collapses whitespace", value: "  Jane \t Austen\n", want: "jane austen"},
+		{name: "folds sharp s", value: "Straße", want: "strasse"},
+		{name: "folds upper case sharp s alike", value: "STRASSE", want: "strasse"},
+		{name: "folds final sigma", value: "ΟΔΥΣΣΕΥΣ", want: "οδυσσευσ"},
+		{name: "composes combining marks", value: "Cafe\u0301", want: "caf\u00e9"},
+		{name: "empty", value: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.value); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// PrincipalKey is the fiber.Ctx local holding the Principal of an
+// authenticated request. Fiber exposes locals through the request context,
+// so services read it back with PrincipalFromContext.
+const PrincipalKey = "principal"
+
+// Principal is the caller a request was authenticated as, together with the
+// permissions granted through its roles. ApiKeyId is set when the caller used
+// an API key instead of an access token.
+type Principal struct {
+	UserId      string
+	Email       string
+	ApiKeyId    string
+	Permissions []string
+}
+
+// Can reports whether the principal holds permission.
+func (p Principal) Can(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+// PrincipalFromContext returns the caller of the request ctx belongs to.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(PrincipalKey).(Principal)
+	return principal, ok
+}
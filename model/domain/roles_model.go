@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"slices"
+	"test-backend-altech/model/web/response"
+	"time"
+)
+
+// Permissions checked by the API. They are stored in the permissions table
+// and granted to roles there.
+const (
+	PermissionAuthorsCreate = "authors.create"
+	PermissionAuthorsUpdate = "authors.update"
+	// PermissionAuthorsUpdateAny allows updating authors created by others,
+	// without it authors.update only covers the caller's own records.
+	PermissionAuthorsUpdateAny = "authors.update_any"
+	PermissionAuthorsDelete    = "authors.delete"
+	PermissionAuthorsMerge     = "authors.merge"
+	PermissionBooksCreate      = "books.create"
+	PermissionBooksUpdate      = "books.update"
+	PermissionBooksUpdateAny   = "books.update_any"
+	PermissionBooksDelete      = "books.delete"
+	PermissionTrashRead        = "trash.read"
+	PermissionUsersManage      = "users.manage"
+	PermissionRolesManage      = "roles.manage"
+	PermissionApiKeysManage    = "apikeys.manage"
+	PermissionAuditRead        = "audit.read"
+	PermissionHistoryRead      = "history.read"
+	PermissionMetricsRead      = "metrics.read"
+)
+
+// Built-in roles. They can be changed but not deleted.
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var BuiltinRoles = []string{RoleReader, RoleEditor, RoleAdmin}
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+func (role *Role) ToRoleResponse() response.RoleResponse {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return response.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+		Builtin:     slices.Contains(BuiltinRoles, role.Name),
+	}
+}
+
+type Permission struct {
+	Name        string
+	Description string
+}
+
+func (permission *Permission) ToPermissionResponse() response.PermissionResponse {
+	return response.PermissionResponse{
+		Name:        permission.Name,
+		Description: permission.Description,
+	}
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"test-backend-altech/model/web/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	Id           string
+	Email        string
+	Name         string
+	PasswordHash string
+	// OIDCIssuer and OIDCSubject identify the user at the identity provider
+	// once it logged in through OpenID Connect.
+	OIDCIssuer  *string
+	OIDCSubject *string
+	Version     int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (user *User) GenerateID() {
+	uuid := uuid.New().String()
+	user.Id = uuid
+}
+
+func (user *User) ToUserResponse() response.UserResponse {
+	return response.UserResponse{
+		Id:        user.Id,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
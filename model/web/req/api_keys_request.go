@@ -0,0 +1,9 @@
+package request
+
+import "time"
+
+type ApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=authors:read authors:write books:read books:write trash:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,11 @@
+package request
+
+// AuditRequest filters GET /audit. from and to are RFC 3339 timestamps and
+// are parsed by the controller.
+type AuditRequest struct {
+	Entity   string `query:"entity" validate:"omitempty,oneof=author book"`
+	EntityId string `query:"entity_id" validate:"omitempty,uuid"`
+	Actor    string `query:"actor" validate:"omitempty,uuid"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PerPage  int    `query:"per_page" validate:"omitempty,min=1,max=100"`
+}
@@ -1,7 +1,9 @@
 package request
 
+import "test-backend-altech/model/civil"
+
 type AuthorRequest struct {
-	Name      string `json:"name"`
-	Bio       string `json:"bio"`
-	BirthDate string `json:"birth_date"`
+	Name      string     `json:"name" validate:"required,max=255"`
+	Bio       string     `json:"bio" validate:"max=5000"`
+	BirthDate civil.Date `json:"birth_date"`
 }
@@ -1,8 +1,11 @@
 package request
 
+import "test-backend-altech/model/civil"
+
 type BookRequest struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description" validate:"required"`
-	AuthorId    string `json:"author_id" validate:"required"`
-	PublishDate string `json:"publish_date" validate:"required"`
+	Title       string         `json:"title" validate:"required,max=255"`
+	Description string         `json:"description" validate:"required"`
+	AuthorId    string         `json:"author_id" validate:"required_without=Author,omitempty,uuid"`
+	PublishDate civil.Date     `json:"publish_date" validate:"required"`
+	Author      *AuthorRequest `json:"author"`
 }
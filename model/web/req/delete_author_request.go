@@ -0,0 +1,16 @@
+package request
+
+// What DELETE /authors/:id does with the books of the author.
+const (
+	OnBooksRestrict = "restrict"
+	OnBooksCascade  = "cascade"
+	OnBooksReassign = "reassign"
+)
+
+// DeleteAuthorRequest is read from the query string of DELETE /authors/:id.
+// OnBooks defaults to restrict; To is the author that receives the books
+// when reassigning.
+type DeleteAuthorRequest struct {
+	OnBooks string `json:"on_books" query:"on_books" validate:"omitempty,oneof=restrict cascade reassign"`
+	To      string `json:"to" query:"to" validate:"required_if=OnBooks reassign,omitempty,uuid"`
+}
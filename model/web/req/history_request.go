@@ -0,0 +1,14 @@
+package request
+
+// VersionDiffRequest selects the two versions compared by
+// GET /authors/:id/diff and GET /books/:id/diff.
+type VersionDiffRequest struct {
+	From int `query:"from" validate:"required,min=1"`
+	To   int `query:"to" validate:"required,min=1"`
+}
+
+// RevertRequest names the version POST /authors/:id/revert and
+// POST /books/:id/revert go back to.
+type RevertRequest struct {
+	Version int `json:"version" validate:"required,min=1"`
+}
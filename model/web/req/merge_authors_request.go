@@ -0,0 +1,15 @@
+package request
+
+// Which side wins when a survivor and its duplicates disagree on bio or
+// birth_date. Empty values never win over filled ones.
+const (
+	PrecedenceSurvivor  = "survivor"
+	PrecedenceDuplicate = "duplicate"
+)
+
+// MergeAuthorsRequest is the body of POST /authors/:id/merge. Duplicates are
+// considered in the given order; Precedence defaults to survivor.
+type MergeAuthorsRequest struct {
+	Duplicates []string `json:"duplicates" validate:"required,min=1,max=100,dive,uuid"`
+	Precedence string   `json:"precedence" validate:"omitempty,oneof=survivor duplicate"`
+}
@@ -0,0 +1,10 @@
+package request
+
+// OIDCCallbackRequest holds the query parameters the identity provider
+// redirects back with. Error is set instead of Code when the login failed.
+type OIDCCallbackRequest struct {
+	Code             string `query:"code" validate:"required_without=Error"`
+	State            string `query:"state" validate:"required"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+}
@@ -0,0 +1,13 @@
+package request
+
+const (
+	MergePatchMediaType = "application/merge-patch+json"
+	JSONPatchMediaType  = "application/json-patch+json"
+)
+
+// PatchRequest is the body of a PATCH request, either an RFC 7396 merge
+// patch or an RFC 6902 JSON patch depending on MediaType.
+type PatchRequest struct {
+	MediaType string
+	Document  []byte
+}
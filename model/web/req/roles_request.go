@@ -0,0 +1,17 @@
+package request
+
+type RoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=64"`
+	Description string   `json:"description" validate:"max=1000"`
+	Permissions []string `json:"permissions" validate:"dive,required,max=64"`
+}
+
+// RolePermissionsRequest replaces every permission of a role.
+type RolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"dive,required,max=64"`
+}
+
+// UserRolesRequest replaces every role of a user.
+type UserRolesRequest struct {
+	Roles []string `json:"roles" validate:"dive,required,max=64"`
+}
@@ -0,0 +1,9 @@
+package request
+
+type UserRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Name     string `json:"name" validate:"max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	// Roles defaults to reader.
+	Roles []string `json:"roles" validate:"dive,required,max=64"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+type ApiKeyResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedApiKeyResponse is only returned when a key is created; the full key
+// is not stored and can not be shown again.
+type CreatedApiKeyResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+type AuditChangeResponse struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditEntryResponse struct {
+	Id        int64                          `json:"id"`
+	ActorId   *string                        `json:"actor_id"`
+	ApiKeyId  *string                        `json:"api_key_id,omitempty"`
+	RequestId string                         `json:"request_id"`
+	Action    string                         `json:"action"`
+	Entity    string                         `json:"entity"`
+	EntityId  string                         `json:"entity_id"`
+	Changes   map[string]AuditChangeResponse `json:"changes"`
+	Details   map[string]interface{}         `json:"details"`
+	CreatedAt time.Time                      `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package response
+
+// TokenResponse is returned by login and refresh. ExpiresIn and
+// RefreshExpiresIn are in seconds.
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
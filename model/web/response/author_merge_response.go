@@ -0,0 +1,12 @@
+package response
+
+import "time"
+
+type AuthorMergeResponse struct {
+	Id         string         `json:"id"`
+	Author     AuthorResponse `json:"author"`
+	Duplicates []string       `json:"duplicates"`
+	Precedence string         `json:"precedence"`
+	BooksMoved int64          `json:"books_moved"`
+	MergedAt   time.Time      `json:"merged_at"`
+}
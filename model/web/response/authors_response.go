@@ -1,8 +1,20 @@
 package response
 
+import (
+	"time"
+
+	"test-backend-altech/model/civil"
+)
+
 type AuthorResponse struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
-	Bio       string `json:"bio"`
-	BirthDate string `json:"birth_date"`
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	Bio       string     `json:"bio"`
+	BirthDate civil.Date `json:"birth_date"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	CreatedBy *string    `json:"created_by"`
+	UpdatedBy *string    `json:"updated_by"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Version   int        `json:"-"`
 }
@@ -1,10 +1,29 @@
 package response
 
+import (
+	"time"
+
+	"test-backend-altech/model/civil"
+)
+
 type BookResponse struct {
-	Id          string `json:"id"`
-	Title       string `json:"title"`
-	AuthorId    string `json:"author_id"`
-	PublishDate string `json:"publish_date"`
-	Description string `json:"description"`
-	AuthorName  string `json:"author_name"`
+	Id          string     `json:"id"`
+	Title       string     `json:"title"`
+	AuthorId    string     `json:"author_id"`
+	PublishDate civil.Date `json:"publish_date"`
+	Description string     `json:"description"`
+	AuthorName  string     `json:"author_name"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CreatedBy   *string    `json:"created_by"`
+	UpdatedBy   *string    `json:"updated_by"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	// ChangedAt is set in lists: the later of updated_at and the author's
+	// updated_at, which lists are ordered by and since is compared with.
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+	Version   int        `json:"-"`
+	// AuthorVersion and AuthorUpdatedAt track the joined author, whose name
+	// is part of the representation.
+	AuthorVersion   int       `json:"-"`
+	AuthorUpdatedAt time.Time `json:"-"`
 }
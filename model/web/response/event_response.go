@@ -0,0 +1,19 @@
+package response
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventMessage is the published form of a domain event. Id grows with every
+// event and is the same on every delivery, so consumers can drop duplicates.
+type EventMessage struct {
+	Id            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	ActorId       *string         `json:"actor_id"`
+	RequestId     string          `json:"request_id"`
+	Data          json.RawMessage `json:"data"`
+}
@@ -0,0 +1,25 @@
+package response
+
+import "time"
+
+type AuthorVersionResponse struct {
+	Version   int            `json:"version"`
+	ValidFrom time.Time      `json:"valid_from"`
+	ValidTo   *time.Time     `json:"valid_to"`
+	Author    AuthorResponse `json:"author"`
+}
+
+type BookVersionResponse struct {
+	Version   int          `json:"version"`
+	ValidFrom time.Time    `json:"valid_from"`
+	ValidTo   *time.Time   `json:"valid_to"`
+	Book      BookResponse `json:"book"`
+}
+
+// VersionDiffResponse lists the fields that differ between two versions of
+// a record.
+type VersionDiffResponse struct {
+	From    int                            `json:"from"`
+	To      int                            `json:"to"`
+	Changes map[string]AuditChangeResponse `json:"changes"`
+}
@@ -0,0 +1,9 @@
+package response
+
+import "time"
+
+// TombstoneResponse stands for a deleted record in lists read with since.
+type TombstoneResponse struct {
+	Id        string    `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
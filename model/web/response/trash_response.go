@@ -0,0 +1,11 @@
+package response
+
+type TrashResponse struct {
+	Authors []AuthorResponse `json:"authors"`
+	Books   []BookResponse   `json:"books"`
+}
+
+type PurgeResponse struct {
+	Authors int64 `json:"authors"`
+	Books   int64 `json:"books"`
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository/query"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type apiKeyRepository struct {
+	db          Store
+	ApiKeyQuery query.ApiKeyQuery
+}
+
+type ApiKeyRepository interface {
+	CreateApiKey(c context.Context, key domain.ApiKey) (domain.ApiKey, error)
+	FindApiKeysByUser(c context.Context, userId string) ([]domain.ApiKey, error)
+	FindApiKeyByPrefix(c context.Context, prefix string) (domain.ApiKey, error)
+	RevokeApiKey(c context.Context, id string, userId string) (domain.ApiKey, error)
+	TouchApiKey(c context.Context, id string) error
+}
+
+func NewApiKeyRepository(db Store, q query.ApiKeyQuery) ApiKeyRepository {
+	return &apiKeyRepository{
+		db:          db,
+		ApiKeyQuery: q,
+	}
+}
+
+func (r *apiKeyRepository) CreateApiKey(c context.Context, key domain.ApiKey) (domain.ApiKey, error) {
+	var err error
+	var created domain.ApiKey
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		created, err = r.ApiKeyQuery.CreateApiKey(c, tx, key)
+		return err
+	})
+
+	return created, err
+}
+
+func (r *apiKeyRepository) FindApiKeysByUser(c context.Context, userId string) ([]domain.ApiKey, error) {
+	var err error
+	var keys []domain.ApiKey
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		keys, err = r.ApiKeyQuery.FindApiKeysByUser(c, tx, userId)
+		return err
+	})
+
+	return keys, err
+}
+
+func (r *apiKeyRepository) FindApiKeyByPrefix(c context.Context, prefix string) (domain.ApiKey, error) {
+	var err error
+	var key domain.ApiKey
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		key, err = r.ApiKeyQuery.FindApiKeyByPrefix(c, tx, prefix)
+		return err
+	})
+
+	return key, err
+}
+
+func (r *apiKeyRepository) RevokeApiKey(c context.Context, id string, userId string) (domain.ApiKey, error) {
+	var err error
+	var key domain.ApiKey
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		key, err = r.ApiKeyQuery.RevokeApiKey(c, tx, id, userId)
+		return err
+	})
+
+	return key, err
+}
+
+func (r *apiKeyRepository) TouchApiKey(c context.Context, id string) error {
+	return r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		return r.ApiKeyQuery.TouchApiKey(c, tx, id)
+	})
+}
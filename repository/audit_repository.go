@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository/query"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type auditRepository struct {
+	db         Store
+	AuditQuery query.AuditQuery
+}
+
+type AuditRepository interface {
+	CreateAuditEntry(c context.Context, entry domain.AuditEntry) error
+	FindAuditEntries(c context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, int, error)
+}
+
+func NewAuditRepository(db Store, q query.AuditQuery) AuditRepository {
+	return &auditRepository{
+		db:         db,
+		AuditQuery: q,
+	}
+}
+
+func (r *auditRepository) CreateAuditEntry(c context.Context, entry domain.AuditEntry) error {
+	return r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		return r.AuditQuery.CreateAuditEntry(c, tx, entry)
+	})
+}
+
+// FindAuditEntries returns one page of entries and the number of entries
+// matching filter.
+func (r *auditRepository) FindAuditEntries(c context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, int, error) {
+	var err error
+	var entries []domain.AuditEntry
+	var total int
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		if total, err = r.AuditQuery.CountAuditEntries(c, tx, filter); err != nil {
+			return err
+		}
+		entries, err = r.AuditQuery.FindAuditEntries(c, tx, filter)
+		return err
+	})
+
+	return entries, total, err
+}
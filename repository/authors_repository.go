@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"test-backend-altech/model/civil"
 	"test-backend-altech/model/domain"
 	"test-backend-altech/repository/query"
 
@@ -17,10 +19,18 @@ type authorRepository struct {
 type AuthorRepository interface {
 	CreateAuthor(c context.Context, author domain.Author) error
 	UpdateAuthor(c context.Context, id string, author domain.UpdateAuthor) error
+	PatchAuthor(c context.Context, id string, version int, changes domain.Changes) error
 	FindByID(c context.Context, id string) (domain.Author, error)
-	ValidateAuthorName(c context.Context, name string) (domain.ValidateAuthorName, error)
-	FindAllAuthor(c context.Context) ([]domain.Author, error)
-	DeleteAuthor(c context.Context, id string) error
+	FindAllAuthor(c context.Context, since time.Time) ([]domain.Author, error)
+	DeleteAuthor(c context.Context, id string, version int) error
+	EarliestPublishDate(c context.Context, id string) (civil.Date, error)
+	RestoreAuthor(c context.Context, id string) error
+	FindDeletedAuthors(c context.Context) ([]domain.Author, error)
+	PurgeAuthors(c context.Context, before time.Time) (int64, error)
+	CreateAuthorMerge(c context.Context, merge domain.AuthorMerge) (time.Time, error)
+	FindAuthorVersions(c context.Context, id string) ([]domain.AuthorVersion, error)
+	FindAuthorVersion(c context.Context, id string, version int) (domain.AuthorVersion, error)
+	FindAuthorAsOf(c context.Context, id string, asOf time.Time) (domain.Author, error)
 }
 
 func NewAuthorRepository(db Store, q query.AuthorQuery) AuthorRepository {
@@ -56,6 +66,19 @@ func (r *authorRepository) UpdateAuthor(c context.Context, id string, author dom
 	return err
 }
 
+func (r *authorRepository) PatchAuthor(c context.Context, id string, version int, changes domain.Changes) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		if err = r.AuthorQuery.PatchAuthor(c, tx, id, version, changes); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return err
+}
+
 func (r *authorRepository) FindByID(c context.Context, id string) (domain.Author, error) {
 	var err error
 	var author domain.Author
@@ -68,37 +91,120 @@ func (r *authorRepository) FindByID(c context.Context, id string) (domain.Author
 	return author, err
 }
 
-func (r *authorRepository) ValidateAuthorName(c context.Context, name string) (domain.ValidateAuthorName, error) {
+func (r *authorRepository) FindAllAuthor(c context.Context, since time.Time) ([]domain.Author, error) {
 	var err error
-	var author domain.ValidateAuthorName
+	var authors []domain.Author
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		author, err = r.AuthorQuery.ValidateAuthorName(c, tx, name)
+		authors, err = r.AuthorQuery.FindAllAuthor(c, tx, since)
 		return err
 	})
 
-	return author, err
+	return authors, err
+}
+
+func (r *authorRepository) DeleteAuthor(c context.Context, id string, version int) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.AuthorQuery.DeleteAuthor(c, tx, id, version)
+		return err
+	})
+
+	return err
 }
 
-func (r *authorRepository) FindAllAuthor(c context.Context) ([]domain.Author, error) {
+func (r *authorRepository) EarliestPublishDate(c context.Context, id string) (civil.Date, error) {
+	var err error
+	var date civil.Date
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		date, err = r.AuthorQuery.EarliestPublishDate(c, tx, id)
+		return err
+	})
+
+	return date, err
+}
+
+func (r *authorRepository) RestoreAuthor(c context.Context, id string) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.AuthorQuery.RestoreAuthor(c, tx, id)
+		return err
+	})
+
+	return err
+}
+
+func (r *authorRepository) FindDeletedAuthors(c context.Context) ([]domain.Author, error) {
 	var err error
 	var authors []domain.Author
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		authors, err = r.AuthorQuery.FindAllAuthor(c, tx)
+		authors, err = r.AuthorQuery.FindDeletedAuthors(c, tx)
 		return err
 	})
 
 	return authors, err
 }
 
-func (r *authorRepository) DeleteAuthor(c context.Context, id string) error {
+func (r *authorRepository) PurgeAuthors(c context.Context, before time.Time) (int64, error) {
 	var err error
+	var purged int64
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		err = r.AuthorQuery.DeleteAuthor(c, tx, id)
+		purged, err = r.AuthorQuery.PurgeAuthors(c, tx, before)
 		return err
 	})
 
-	return err
+	return purged, err
+}
+
+func (r *authorRepository) CreateAuthorMerge(c context.Context, merge domain.AuthorMerge) (time.Time, error) {
+	var err error
+	var mergedAt time.Time
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		mergedAt, err = r.AuthorQuery.CreateAuthorMerge(c, tx, merge)
+		return err
+	})
+
+	return mergedAt, err
+}
+
+func (r *authorRepository) FindAuthorVersions(c context.Context, id string) ([]domain.AuthorVersion, error) {
+	var err error
+	var versions []domain.AuthorVersion
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		versions, err = r.AuthorQuery.FindAuthorVersions(c, tx, id)
+		return err
+	})
+
+	return versions, err
+}
+
+func (r *authorRepository) FindAuthorVersion(c context.Context, id string, version int) (domain.AuthorVersion, error) {
+	var err error
+	var data domain.AuthorVersion
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		data, err = r.AuthorQuery.FindAuthorVersion(c, tx, id, version)
+		return err
+	})
+
+	return data, err
+}
+
+func (r *authorRepository) FindAuthorAsOf(c context.Context, id string, asOf time.Time) (domain.Author, error) {
+	var err error
+	var author domain.Author
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		author, err = r.AuthorQuery.FindAuthorAsOf(c, tx, id, asOf)
+		return err
+	})
+
+	return author, err
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"test-backend-altech/model/domain"
 	"test-backend-altech/model/web/response"
@@ -18,10 +19,19 @@ type bookRepository struct {
 type BookRepository interface {
 	CreateBook(c context.Context, book domain.Book) error
 	UpdateBook(c context.Context, id string, book domain.UpdateBook) error
+	PatchBook(c context.Context, id string, version int, changes domain.Changes) error
 	FindByID(c context.Context, id string) (response.BookResponse, error)
-	ValidateBookTitle(c context.Context, name string) (domain.ValidateBookTitle, error)
-	FindAllBook(c context.Context) ([]response.BookResponse, error)
-	DeleteBook(c context.Context, id string) error
+	FindAllBook(c context.Context, since time.Time) ([]response.BookResponse, error)
+	DeleteBook(c context.Context, id string, version int) error
+	RestoreBook(c context.Context, id string) error
+	FindDeletedBooks(c context.Context) ([]response.BookResponse, error)
+	PurgeBooks(c context.Context, before time.Time) (int64, error)
+	FindReferencesByAuthor(c context.Context, authorId string) ([]domain.Reference, error)
+	DeleteBooksByAuthor(c context.Context, authorId string) (int64, error)
+	ReassignBooks(c context.Context, fromAuthorId string, toAuthorId string) (int64, error)
+	FindBookVersions(c context.Context, id string) ([]domain.BookVersion, error)
+	FindBookVersion(c context.Context, id string, version int) (domain.BookVersion, error)
+	FindBookAsOf(c context.Context, id string, asOf time.Time) (response.BookResponse, error)
 }
 
 func NewBookRepository(db Store, q query.BookQuery) BookRepository {
@@ -58,6 +68,19 @@ func (r *bookRepository) UpdateBook(c context.Context, id string, book domain.Up
 	return err
 }
 
+func (r *bookRepository) PatchBook(c context.Context, id string, version int, changes domain.Changes) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		if err = r.BookQuery.PatchBook(c, tx, id, version, changes); err != nil {
+			return err
+		}
+		return nil
+	})
+
+	return err
+}
+
 func (r *bookRepository) FindByID(c context.Context, id string) (response.BookResponse, error) {
 	var err error
 	var book response.BookResponse
@@ -70,37 +93,132 @@ func (r *bookRepository) FindByID(c context.Context, id string) (response.BookRe
 	return book, err
 }
 
-func (r *bookRepository) ValidateBookTitle(c context.Context, name string) (domain.ValidateBookTitle, error) {
+func (r *bookRepository) FindAllBook(c context.Context, since time.Time) ([]response.BookResponse, error) {
 	var err error
-	var book domain.ValidateBookTitle
+	var books []response.BookResponse
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		book, err = r.BookQuery.ValidateBookTitle(c, tx, name)
+		books, err = r.BookQuery.FindAllBook(c, tx, since)
 		return err
 	})
 
-	return book, err
+	return books, err
+}
+
+func (r *bookRepository) DeleteBook(c context.Context, id string, version int) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.BookQuery.DeleteBook(c, tx, id, version)
+		return err
+	})
+
+	return err
+}
+
+func (r *bookRepository) RestoreBook(c context.Context, id string) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.BookQuery.RestoreBook(c, tx, id)
+		return err
+	})
+
+	return err
 }
 
-func (r *bookRepository) FindAllBook(c context.Context) ([]response.BookResponse, error) {
+func (r *bookRepository) FindDeletedBooks(c context.Context) ([]response.BookResponse, error) {
 	var err error
 	var books []response.BookResponse
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		books, err = r.BookQuery.FindAllBook(c, tx)
+		books, err = r.BookQuery.FindDeletedBooks(c, tx)
 		return err
 	})
 
 	return books, err
 }
 
-func (r *bookRepository) DeleteBook(c context.Context, id string) error {
+func (r *bookRepository) PurgeBooks(c context.Context, before time.Time) (int64, error) {
 	var err error
+	var purged int64
 
 	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
-		err = r.BookQuery.DeleteBook(c, tx, id)
+		purged, err = r.BookQuery.PurgeBooks(c, tx, before)
 		return err
 	})
 
-	return err
+	return purged, err
+}
+
+func (r *bookRepository) FindReferencesByAuthor(c context.Context, authorId string) ([]domain.Reference, error) {
+	var err error
+	var references []domain.Reference
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		references, err = r.BookQuery.FindReferencesByAuthor(c, tx, authorId)
+		return err
+	})
+
+	return references, err
+}
+
+func (r *bookRepository) DeleteBooksByAuthor(c context.Context, authorId string) (int64, error) {
+	var err error
+	var deleted int64
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		deleted, err = r.BookQuery.DeleteBooksByAuthor(c, tx, authorId)
+		return err
+	})
+
+	return deleted, err
+}
+
+func (r *bookRepository) ReassignBooks(c context.Context, fromAuthorId string, toAuthorId string) (int64, error) {
+	var err error
+	var moved int64
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		moved, err = r.BookQuery.ReassignBooks(c, tx, fromAuthorId, toAuthorId)
+		return err
+	})
+
+	return moved, err
+}
+
+func (r *bookRepository) FindBookVersions(c context.Context, id string) ([]domain.BookVersion, error) {
+	var err error
+	var versions []domain.BookVersion
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		versions, err = r.BookQuery.FindBookVersions(c, tx, id)
+		return err
+	})
+
+	return versions, err
+}
+
+func (r *bookRepository) FindBookVersion(c context.Context, id string, version int) (domain.BookVersion, error) {
+	var err error
+	var data domain.BookVersion
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		data, err = r.BookQuery.FindBookVersion(c, tx, id, version)
+		return err
+	})
+
+	return data, err
+}
+
+func (r *bookRepository) FindBookAsOf(c context.Context, id string, asOf time.Time) (response.BookResponse, error) {
+	var err error
+	var book response.BookResponse
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		book, err = r.BookQuery.FindBookAsOf(c, tx, id, asOf)
+		return err
+	})
+
+	return book, err
 }
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository/query"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type outboxRepository struct {
+	db          Store
+	OutboxQuery query.OutboxQuery
+}
+
+type OutboxRepository interface {
+	CreateOutboxEvent(c context.Context, event domain.OutboxEvent) error
+	LockOutbox(c context.Context) (bool, error)
+	FindPendingOutboxEvents(c context.Context, limit int) ([]domain.OutboxEvent, error)
+	MarkOutboxEventsPublished(c context.Context, ids []int64) error
+	RecordOutboxFailure(c context.Context, id int64, reason string, maxAttempts int) (bool, error)
+	DeletePublishedOutboxEvents(c context.Context, before time.Time) (int64, error)
+}
+
+func NewOutboxRepository(db Store, q query.OutboxQuery) OutboxRepository {
+	return &outboxRepository{
+		db:          db,
+		OutboxQuery: q,
+	}
+}
+
+func (r *outboxRepository) CreateOutboxEvent(c context.Context, event domain.OutboxEvent) error {
+	return r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		return r.OutboxQuery.CreateOutboxEvent(c, tx, event)
+	})
+}
+
+// LockOutbox only makes sense inside a unit of work, the lock is released
+// when its transaction ends.
+func (r *outboxRepository) LockOutbox(c context.Context) (bool, error) {
+	var err error
+	var locked bool
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		locked, err = r.OutboxQuery.LockOutbox(c, tx)
+		return err
+	})
+
+	return locked, err
+}
+
+func (r *outboxRepository) FindPendingOutboxEvents(c context.Context, limit int) ([]domain.OutboxEvent, error) {
+	var err error
+	var events []domain.OutboxEvent
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		events, err = r.OutboxQuery.FindPendingOutboxEvents(c, tx, limit)
+		return err
+	})
+
+	return events, err
+}
+
+func (r *outboxRepository) MarkOutboxEventsPublished(c context.Context, ids []int64) error {
+	return r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		return r.OutboxQuery.MarkOutboxEventsPublished(c, tx, ids)
+	})
+}
+
+func (r *outboxRepository) RecordOutboxFailure(c context.Context, id int64, reason string, maxAttempts int) (bool, error) {
+	var err error
+	var deadLettered bool
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		deadLettered, err = r.OutboxQuery.RecordOutboxFailure(c, tx, id, reason, maxAttempts)
+		return err
+	})
+
+	return deadLettered, err
+}
+
+func (r *outboxRepository) DeletePublishedOutboxEvents(c context.Context, before time.Time) (int64, error) {
+	var err error
+	var deleted int64
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		deleted, err = r.OutboxQuery.DeletePublishedOutboxEvents(c, tx, before)
+		return err
+	})
+
+	return deleted, err
+}
@@ -0,0 +1,142 @@
+package query
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ApiKeyQuery interface {
+	CreateApiKey(c context.Context, tx pgx.Tx, key domain.ApiKey) (domain.ApiKey, error)
+	FindApiKeysByUser(c context.Context, tx pgx.Tx, userId string) ([]domain.ApiKey, error)
+	FindApiKeyByPrefix(c context.Context, tx pgx.Tx, prefix string) (domain.ApiKey, error)
+	RevokeApiKey(c context.Context, tx pgx.Tx, id string, userId string) (domain.ApiKey, error)
+	TouchApiKey(c context.Context, tx pgx.Tx, id string) error
+}
+
+type ApiKeyQueryImpl struct {
+}
+
+func NewApiKey() ApiKeyQuery {
+	return &ApiKeyQueryImpl{}
+}
+
+const apiKeyColumns = `
+			k.id,
+			k.user_id,
+			k.name,
+			k.prefix,
+			k.secret_hash,
+			k.scopes,
+			k.expires_at,
+			k.last_used_at,
+			k.revoked_at,
+			k.created_at`
+
+func (repository *ApiKeyQueryImpl) CreateApiKey(c context.Context, tx pgx.Tx, key domain.ApiKey) (domain.ApiKey, error) {
+	query := `INSERT INTO api_keys AS k
+	(
+		"id",
+		"user_id",
+		"name",
+		"prefix",
+		"secret_hash",
+		"scopes",
+		"expires_at"
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)
+	RETURNING` + apiKeyColumns
+
+	return scanApiKey(tx.QueryRow(c, query,
+		key.Id,
+		key.UserId,
+		key.Name,
+		key.Prefix,
+		key.SecretHash,
+		key.Scopes,
+		key.ExpiresAt))
+}
+
+// FindApiKeysByUser lists every key of a user, revoked ones included.
+func (repository *ApiKeyQueryImpl) FindApiKeysByUser(c context.Context, tx pgx.Tx, userId string) ([]domain.ApiKey, error) {
+	query := `
+		SELECT` + apiKeyColumns + `
+		FROM
+			api_keys AS k
+		WHERE
+			k.user_id = $1
+		ORDER BY k.created_at, k.id`
+
+	rows, err := tx.Query(c, query, userId)
+	if err != nil {
+		return nil, translateError(domain.EntityApiKey, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.ApiKey
+	for rows.Next() {
+		data, err := scanApiKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *ApiKeyQueryImpl) FindApiKeyByPrefix(c context.Context, tx pgx.Tx, prefix string) (domain.ApiKey, error) {
+	query := `
+		SELECT` + apiKeyColumns + `
+		FROM
+			api_keys AS k
+		WHERE
+			k.prefix = $1`
+
+	return scanApiKey(tx.QueryRow(c, query, prefix))
+}
+
+// RevokeApiKey revokes a key of the given user. Revoking a key twice keeps
+// the first revocation time.
+func (repository *ApiKeyQueryImpl) RevokeApiKey(c context.Context, tx pgx.Tx, id string, userId string) (domain.ApiKey, error) {
+	query := `
+		UPDATE api_keys AS k
+		SET revoked_at = COALESCE(k.revoked_at, timezone('utc', now()))
+		WHERE k.id = $1 AND k.user_id = $2
+		RETURNING` + apiKeyColumns
+
+	return scanApiKey(tx.QueryRow(c, query, id, userId))
+}
+
+// TouchApiKey records that a key was used. It writes at most once a minute
+// per key so busy clients do not turn every request into a write.
+func (repository *ApiKeyQueryImpl) TouchApiKey(c context.Context, tx pgx.Tx, id string) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = timezone('utc', now())
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < timezone('utc', now()) - interval '1 minute')`
+
+	_, err := tx.Exec(c, query, id)
+	return translateError(domain.EntityApiKey, err)
+}
+
+func scanApiKey(row pgx.Row) (domain.ApiKey, error) {
+	var data domain.ApiKey
+	if err := row.Scan(
+		&data.Id,
+		&data.UserId,
+		&data.Name,
+		&data.Prefix,
+		&data.SecretHash,
+		&data.Scopes,
+		&data.ExpiresAt,
+		&data.LastUsedAt,
+		&data.RevokedAt,
+		&data.CreatedAt,
+	); err != nil {
+		return domain.ApiKey{}, translateError(domain.EntityApiKey, err)
+	}
+	return data, nil
+}
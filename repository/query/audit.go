@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditQuery interface {
+	CreateAuditEntry(c context.Context, tx pgx.Tx, entry domain.AuditEntry) error
+	FindAuditEntries(c context.Context, tx pgx.Tx, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+	CountAuditEntries(c context.Context, tx pgx.Tx, filter domain.AuditFilter) (int, error)
+}
+
+type AuditQueryImpl struct {
+}
+
+func NewAudit() AuditQuery {
+	return &AuditQueryImpl{}
+}
+
+func (repository *AuditQueryImpl) CreateAuditEntry(c context.Context, tx pgx.Tx, entry domain.AuditEntry) error {
+	query := `INSERT INTO audit_log
+	(
+		"actor_id",
+		"api_key_id",
+		"request_id",
+		"action",
+		"entity",
+		"entity_id",
+		"changes",
+		"details"
+	)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+
+	_, err := tx.Exec(c, query,
+		entry.ActorId,
+		entry.ApiKeyId,
+		entry.RequestId,
+		entry.Action,
+		entry.Entity,
+		entry.EntityId,
+		entry.Changes,
+		entry.Details)
+
+	return translateError(domain.EntityAuditEntry, err)
+}
+
+// FindAuditEntries returns the entries matching filter, newest first.
+func (repository *AuditQueryImpl) FindAuditEntries(c context.Context, tx pgx.Tx, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	where, args := auditWhere(filter)
+	args = append(args, filter.Limit, filter.Offset)
+	query := `
+		SELECT
+			l.id,
+			l.actor_id,
+			l.api_key_id,
+			l.request_id,
+			l.action,
+			l.entity,
+			l.entity_id,
+			l.changes,
+			l.details,
+			l.created_at
+		FROM audit_log AS l` + where + `
+		ORDER BY l.id DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := tx.Query(c, query, args...)
+	if err != nil {
+		return nil, translateError(domain.EntityAuditEntry, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.AuditEntry
+	for rows.Next() {
+		var data domain.AuditEntry
+		if err := rows.Scan(
+			&data.Id,
+			&data.ActorId,
+			&data.ApiKeyId,
+			&data.RequestId,
+			&data.Action,
+			&data.Entity,
+			&data.EntityId,
+			&data.Changes,
+			&data.Details,
+			&data.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *AuditQueryImpl) CountAuditEntries(c context.Context, tx pgx.Tx, filter domain.AuditFilter) (int, error) {
+	where, args := auditWhere(filter)
+
+	var count int
+	if err := tx.QueryRow(c, `SELECT count(*) FROM audit_log AS l`+where, args...).Scan(&count); err != nil {
+		return 0, translateError(domain.EntityAuditEntry, err)
+	}
+	return count, nil
+}
+
+// auditWhere builds the WHERE clause of filter, from is inclusive and to
+// exclusive.
+func auditWhere(filter domain.AuditFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if filter.Entity != "" {
+		add("l.entity = ?", filter.Entity)
+	}
+	if filter.EntityId != "" {
+		add("l.entity_id = ?", filter.EntityId)
+	}
+	if filter.ActorId != "" {
+		add("l.actor_id = ?", filter.ActorId)
+	}
+	if !filter.From.IsZero() {
+		add("l.created_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("l.created_at < ?", filter.To.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
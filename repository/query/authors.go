@@ -3,6 +3,9 @@ package query
 import (
 	"context"
 	"log"
+	"time"
+
+	"test-backend-altech/model/civil"
 	"test-backend-altech/model/domain"
 
 	"github.com/jackc/pgx/v5"
@@ -11,10 +14,18 @@ import (
 type AuthorQuery interface {
 	CreateAuthor(c context.Context, tx pgx.Tx, author domain.Author) error
 	UpdateAuthor(c context.Context, tx pgx.Tx, id string, author domain.UpdateAuthor) error
+	PatchAuthor(c context.Context, tx pgx.Tx, id string, version int, changes domain.Changes) error
 	FindByID(c context.Context, tx pgx.Tx, id string) (domain.Author, error)
-	ValidateAuthorName(c context.Context, tx pgx.Tx, id string) (domain.ValidateAuthorName, error)
-	FindAllAuthor(c context.Context, tx pgx.Tx) ([]domain.Author, error)
-	DeleteAuthor(c context.Context, tx pgx.Tx, id string) error
+	FindAllAuthor(c context.Context, tx pgx.Tx, since time.Time) ([]domain.Author, error)
+	DeleteAuthor(c context.Context, tx pgx.Tx, id string, version int) error
+	EarliestPublishDate(c context.Context, tx pgx.Tx, id string) (civil.Date, error)
+	RestoreAuthor(c context.Context, tx pgx.Tx, id string) error
+	FindDeletedAuthors(c context.Context, tx pgx.Tx) ([]domain.Author, error)
+	PurgeAuthors(c context.Context, tx pgx.Tx, before time.Time) (int64, error)
+	CreateAuthorMerge(c context.Context, tx pgx.Tx, merge domain.AuthorMerge) (time.Time, error)
+	FindAuthorVersions(c context.Context, tx pgx.Tx, id string) ([]domain.AuthorVersion, error)
+	FindAuthorVersion(c context.Context, tx pgx.Tx, id string, version int) (domain.AuthorVersion, error)
+	FindAuthorAsOf(c context.Context, tx pgx.Tx, id string, asOf time.Time) (domain.Author, error)
 }
 
 type AuthorQueryImpl struct {
@@ -32,7 +43,7 @@ func (repository *AuthorQueryImpl) CreateAuthor(c context.Context, tx pgx.Tx, au
 		"name",
 		"bio",
 		"birth_date",
-		"created_at"
+		"name_normalized"
 	) 
 	VALUES ($1,$2,$3,$4,$5)`
 
@@ -41,27 +52,59 @@ func (repository *AuthorQueryImpl) CreateAuthor(c context.Context, tx pgx.Tx, au
 		author.Name,
 		author.Bio,
 		author.BirthDate,
-		author.CreatedAt)
+		domain.NormalizeName(author.Name))
 
-	return err
+	return translateError(domain.EntityAuthor, err)
 }
 
 func (repository *AuthorQueryImpl) UpdateAuthor(c context.Context, tx pgx.Tx, id string, author domain.UpdateAuthor) error {
 	query := `UPDATE  authors SET 
 	 name=$1,
 	 bio=$2,
-	 birth_date=$3
-	 WHERE id=$4
+	 birth_date=$3,
+	 name_normalized=$4,
+	 version=version+1
+	 WHERE id=$5 AND deleted_at IS NULL AND ($6 = 0 OR version=$6)
 	`
 
-	_, err := tx.Exec(c, query,
+	tag, err := tx.Exec(c, query,
 		author.Name,
 		author.Bio,
 		author.BirthDate,
-		id)
+		domain.NormalizeName(author.Name),
+		id,
+		author.Version)
+	if err != nil {
+		return translateError(domain.EntityAuthor, err)
+	}
 
-	return err
+	return checkVersionedWrite(c, tx, "authors", domain.EntityAuthor, id, tag)
 }
+
+// authorPatchColumns are the columns PATCH /authors/:id may change.
+// name_normalized follows name and is never patched on its own.
+var authorPatchColumns = map[string]bool{
+	"name":            true,
+	"bio":             true,
+	"birth_date":      true,
+	"name_normalized": true,
+}
+
+func (repository *AuthorQueryImpl) PatchAuthor(c context.Context, tx pgx.Tx, id string, version int, changes domain.Changes) error {
+	changes = withNormalized(changes, "name", "name_normalized")
+	query, args, err := buildPatchQuery("authors", authorPatchColumns, id, version, changes)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(c, query, args...)
+	if err != nil {
+		return translateError(domain.EntityAuthor, err)
+	}
+
+	return checkVersionedWrite(c, tx, "authors", domain.EntityAuthor, id, tag)
+}
+
 func (repository *AuthorQueryImpl) FindByID(c context.Context, tx pgx.Tx, id string) (domain.Author, error) {
 	query := `
         SELECT
@@ -69,11 +112,15 @@ func (repository *AuthorQueryImpl) FindByID(c context.Context, tx pgx.Tx, id str
 			a.name,
 			a.bio,
 			a.birth_date,
-			a.created_at
+			a.version,
+			a.created_at,
+			a.updated_at,
+			a.created_by,
+			a.updated_by
         FROM
             authors AS a
         WHERE
-            a.id = $1;
+            a.id = $1 AND a.deleted_at IS NULL;
     `
 
 	row := tx.QueryRow(c, query, id)
@@ -83,74 +130,292 @@ func (repository *AuthorQueryImpl) FindByID(c context.Context, tx pgx.Tx, id str
 		&data.Name,
 		&data.Bio,
 		&data.BirthDate,
+		&data.Version,
 		&data.CreatedAt,
+		&data.UpdatedAt,
+		&data.CreatedBy,
+		&data.UpdatedBy,
 	); err != nil {
 		log.Println("Scan", err)
-		return domain.Author{}, err
+		return domain.Author{}, translateError(domain.EntityAuthor, err)
 	}
 
 	return data, nil
 }
 
-func (repository *AuthorQueryImpl) ValidateAuthorName(c context.Context, tx pgx.Tx, name string) (domain.ValidateAuthorName, error) {
+// FindAllAuthor lists the active authors changed at or after since. With a
+// since it also lists the authors deleted since then, which deleting moves
+// to the same updated_at.
+func (repository *AuthorQueryImpl) FindAllAuthor(c context.Context, tx pgx.Tx, since time.Time) ([]domain.Author, error) {
+
+	query :=
+		`SELECT
+		 a.id,
+		 a.name,
+		 a.bio,
+		 a.birth_date,
+		 a.version,
+		 a.created_at,
+		 a.updated_at,
+		 a.created_by,
+		 a.updated_by,
+		 a.deleted_at
+			FROM authors AS a
+			WHERE (a.deleted_at IS NULL AND a.updated_at >= $1) OR ($2 AND a.deleted_at >= $1)
+			ORDER BY a.updated_at, a.id`
+
+	rows, err := tx.Query(c, query, since.UTC(), !since.IsZero())
+	if err != nil {
+		return nil, translateError(domain.EntityAuthor, err)
+	}
+
+	var datas []domain.Author
+	for rows.Next() {
+		var data domain.Author
+		err := rows.Scan(&data.Id, &data.Name, &data.Bio, &data.BirthDate, &data.Version, &data.CreatedAt, &data.UpdatedAt, &data.CreatedBy, &data.UpdatedBy, &data.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+
+	defer rows.Close()
+	return datas, nil
+}
+
+func (repository *AuthorQueryImpl) DeleteAuthor(c context.Context, tx pgx.Tx, id string, version int) error {
+
+	query := `UPDATE authors SET
+	 deleted_at=timezone('utc', now()),
+	 version=version+1
+	 WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+
+	tag, err := tx.Exec(c, query, id, version)
+	if err != nil {
+		return translateError(domain.EntityAuthor, err)
+	}
+
+	return checkVersionedWrite(c, tx, "authors", domain.EntityAuthor, id, tag)
+}
+
+func (repository *AuthorQueryImpl) EarliestPublishDate(c context.Context, tx pgx.Tx, id string) (civil.Date, error) {
 	query := `
         SELECT
-			a.name
+			MIN(b.publish_date)
         FROM
-            authors AS a
+            books AS b
         WHERE
-            a.name = $1;
+            b.author_id = $1 AND b.deleted_at IS NULL;
     `
 
-	row := tx.QueryRow(c, query, name)
-	var data domain.ValidateAuthorName
-	if err := row.Scan(
-		&data.Name,
-	); err != nil && err != pgx.ErrNoRows {
+	var date civil.Date
+	if err := tx.QueryRow(c, query, id).Scan(&date); err != nil {
 		log.Println("Scan", err)
-		return domain.ValidateAuthorName{}, err
+		return civil.Date{}, translateError(domain.EntityAuthor, err)
 	}
 
-	return data, nil
+	return date, nil
 }
 
-func (repository *AuthorQueryImpl) FindAllAuthor(c context.Context, tx pgx.Tx) ([]domain.Author, error) {
+func (repository *AuthorQueryImpl) RestoreAuthor(c context.Context, tx pgx.Tx, id string) error {
+	query := `UPDATE authors SET
+	 deleted_at=NULL,
+	 version=version+1
+	 WHERE id = $1 AND deleted_at IS NOT NULL`
 
+	tag, err := tx.Exec(c, query, id)
+	if err != nil {
+		return translateError(domain.EntityAuthor, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityAuthor}
+	}
+	return nil
+}
+
+func (repository *AuthorQueryImpl) FindDeletedAuthors(c context.Context, tx pgx.Tx) ([]domain.Author, error) {
 	query :=
 		`SELECT
 		 a.id,
 		 a.name,
 		 a.bio,
-		 a.birth_date
-			FROM authors AS a`
+		 a.birth_date,
+		 a.version,
+		 a.created_at,
+		 a.updated_at,
+		 a.created_by,
+		 a.updated_by,
+		 a.deleted_at
+			FROM authors AS a
+			WHERE a.deleted_at IS NOT NULL
+			ORDER BY a.deleted_at DESC, a.id`
 
 	rows, err := tx.Query(c, query)
 	if err != nil {
-		return nil, err
+		return nil, translateError(domain.EntityAuthor, err)
 	}
+	defer rows.Close()
 
 	var datas []domain.Author
 	for rows.Next() {
 		var data domain.Author
-		err := rows.Scan(&data.Id, &data.Name, &data.Bio, &data.BirthDate)
+		err := rows.Scan(&data.Id, &data.Name, &data.Bio, &data.BirthDate, &data.Version, &data.CreatedAt, &data.UpdatedAt, &data.CreatedBy, &data.UpdatedBy, &data.DeletedAt)
 		if err != nil {
 			return nil, err
 		}
 		datas = append(datas, data)
 	}
+	return datas, rows.Err()
+}
 
-	defer rows.Close()
-	return datas, nil
+// PurgeAuthors hard-deletes authors that were moved to the trash before the
+// given time. Authors still referenced by a book, even one in the trash, are
+// kept until that book is purged.
+func (repository *AuthorQueryImpl) PurgeAuthors(c context.Context, tx pgx.Tx, before time.Time) (int64, error) {
+	query := `DELETE FROM authors AS a
+	 WHERE a.deleted_at < $1
+	 AND NOT EXISTS (SELECT 1 FROM books AS b WHERE b.author_id = a.id)`
+
+	tag, err := tx.Exec(c, query, before.UTC())
+	if err != nil {
+		return 0, translateError(domain.EntityAuthor, err)
+	}
+	return tag.RowsAffected(), nil
 }
 
-func (repository *AuthorQueryImpl) DeleteAuthor(c context.Context, tx pgx.Tx, id string) error {
+// CreateAuthorMerge records a merge and returns the time it was stored at.
+func (repository *AuthorQueryImpl) CreateAuthorMerge(c context.Context, tx pgx.Tx, merge domain.AuthorMerge) (time.Time, error) {
+	query := `INSERT INTO author_merges 
+	(
+		"id", 
+		"survivor_id",
+		"duplicate_ids",
+		"precedence",
+		"books_moved"
+	) 
+	VALUES ($1,$2,$3,$4,$5)
+	RETURNING merged_at`
+
+	var mergedAt time.Time
+	err := tx.QueryRow(c, query,
+		merge.Id,
+		merge.SurvivorId,
+		merge.DuplicateIds,
+		merge.Precedence,
+		merge.BooksMoved).Scan(&mergedAt)
+
+	return mergedAt, translateError(domain.EntityAuthor, err)
+}
 
-	query := `DELETE FROM authors WHERE id = $1`
+// authorVersionsQuery selects the versions of author $1 from the history,
+// only version $2 unless it is 0. valid_to is when the next version was
+// written.
+const authorVersionsQuery = `
+	SELECT
+		v.id,
+		v.name,
+		v.bio,
+		v.birth_date,
+		v.version,
+		v.created_at,
+		v.updated_at,
+		v.created_by,
+		v.updated_by,
+		v.deleted_at,
+		v.valid_from,
+		v.valid_to
+	FROM (
+		SELECT h.*, LEAD(h.valid_from) OVER (ORDER BY h.version) AS valid_to
+		FROM authors_history AS h
+		WHERE h.id = $1
+	) AS v
+	WHERE ($2 = 0 OR v.version = $2)
+	ORDER BY v.version`
 
-	_, err := tx.Exec(c, query, id)
+func scanAuthorVersion(row pgx.Row) (domain.AuthorVersion, error) {
+	var data domain.AuthorVersion
+	err := row.Scan(
+		&data.Author.Id,
+		&data.Author.Name,
+		&data.Author.Bio,
+		&data.Author.BirthDate,
+		&data.Author.Version,
+		&data.Author.CreatedAt,
+		&data.Author.UpdatedAt,
+		&data.Author.CreatedBy,
+		&data.Author.UpdatedBy,
+		&data.Author.DeletedAt,
+		&data.ValidFrom,
+		&data.ValidTo,
+	)
+	return data, err
+}
+
+// FindAuthorVersions returns every recorded version of the author, oldest
+// first, including the versions written while it was in the trash.
+func (repository *AuthorQueryImpl) FindAuthorVersions(c context.Context, tx pgx.Tx, id string) ([]domain.AuthorVersion, error) {
+	rows, err := tx.Query(c, authorVersionsQuery, id, 0)
 	if err != nil {
-		return err
+		return nil, translateError(domain.EntityAuthor, err)
 	}
+	defer rows.Close()
 
-	return nil
+	var datas []domain.AuthorVersion
+	for rows.Next() {
+		data, err := scanAuthorVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *AuthorQueryImpl) FindAuthorVersion(c context.Context, tx pgx.Tx, id string, version int) (domain.AuthorVersion, error) {
+	data, err := scanAuthorVersion(tx.QueryRow(c, authorVersionsQuery, id, version))
+	if err != nil {
+		return domain.AuthorVersion{}, translateError(domain.EntityAuthorVersion, err)
+	}
+	return data, nil
+}
+
+// FindAuthorAsOf returns the author as it was at asOf. An author that did
+// not exist yet or was in the trash at that time is not found.
+func (repository *AuthorQueryImpl) FindAuthorAsOf(c context.Context, tx pgx.Tx, id string, asOf time.Time) (domain.Author, error) {
+	query := `
+		SELECT
+			h.id,
+			h.name,
+			h.bio,
+			h.birth_date,
+			h.version,
+			h.created_at,
+			h.updated_at,
+			h.created_by,
+			h.updated_by,
+			h.deleted_at
+		FROM authors_history AS h
+		WHERE h.id = $1 AND h.valid_from <= $2
+		ORDER BY h.version DESC
+		LIMIT 1`
+
+	var data domain.Author
+	if err := tx.QueryRow(c, query, id, asOf.UTC()).Scan(
+		&data.Id,
+		&data.Name,
+		&data.Bio,
+		&data.BirthDate,
+		&data.Version,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+		&data.CreatedBy,
+		&data.UpdatedBy,
+		&data.DeletedAt,
+	); err != nil {
+		return domain.Author{}, translateError(domain.EntityAuthor, err)
+	}
+	if data.DeletedAt != nil {
+		return domain.Author{}, &domain.NotFoundError{Entity: domain.EntityAuthor}
+	}
+	return data, nil
 }
@@ -3,6 +3,8 @@ package query
 import (
 	"context"
 	"log"
+	"time"
+
 	"test-backend-altech/model/domain"
 	"test-backend-altech/model/web/response"
 
@@ -12,10 +14,19 @@ import (
 type BookQuery interface {
 	CreateBook(c context.Context, tx pgx.Tx, book domain.Book) error
 	UpdateBook(c context.Context, tx pgx.Tx, id string, book domain.UpdateBook) error
+	PatchBook(c context.Context, tx pgx.Tx, id string, version int, changes domain.Changes) error
 	FindByID(c context.Context, tx pgx.Tx, id string) (response.BookResponse, error)
-	ValidateBookTitle(c context.Context, tx pgx.Tx, id string) (domain.ValidateBookTitle, error)
-	FindAllBook(c context.Context, tx pgx.Tx) ([]response.BookResponse, error)
-	DeleteBook(c context.Context, tx pgx.Tx, id string) error
+	FindAllBook(c context.Context, tx pgx.Tx, since time.Time) ([]response.BookResponse, error)
+	DeleteBook(c context.Context, tx pgx.Tx, id string, version int) error
+	RestoreBook(c context.Context, tx pgx.Tx, id string) error
+	FindDeletedBooks(c context.Context, tx pgx.Tx) ([]response.BookResponse, error)
+	PurgeBooks(c context.Context, tx pgx.Tx, before time.Time) (int64, error)
+	FindReferencesByAuthor(c context.Context, tx pgx.Tx, authorId string) ([]domain.Reference, error)
+	DeleteBooksByAuthor(c context.Context, tx pgx.Tx, authorId string) (int64, error)
+	ReassignBooks(c context.Context, tx pgx.Tx, fromAuthorId string, toAuthorId string) (int64, error)
+	FindBookVersions(c context.Context, tx pgx.Tx, id string) ([]domain.BookVersion, error)
+	FindBookVersion(c context.Context, tx pgx.Tx, id string, version int) (domain.BookVersion, error)
+	FindBookAsOf(c context.Context, tx pgx.Tx, id string, asOf time.Time) (response.BookResponse, error)
 }
 
 type BookQueryImpl struct {
@@ -34,7 +45,7 @@ func (repository *BookQueryImpl) CreateBook(c context.Context, tx pgx.Tx, book d
 		"description",
 		"author_id",
 		"publish_date",
-		"created_at"
+		"title_normalized"
 	) 
 	VALUES ($1,$2,$3,$4,$5,$6)`
 
@@ -44,9 +55,9 @@ func (repository *BookQueryImpl) CreateBook(c context.Context, tx pgx.Tx, book d
 		book.Description,
 		book.AuthorId,
 		book.PublishDate,
-		book.CreatedAt)
+		domain.NormalizeName(book.Title))
 
-	return err
+	return translateError(domain.EntityBook, err)
 }
 
 func (repository *BookQueryImpl) UpdateBook(c context.Context, tx pgx.Tx, id string, book domain.UpdateBook) error {
@@ -54,19 +65,52 @@ func (repository *BookQueryImpl) UpdateBook(c context.Context, tx pgx.Tx, id str
 	 title=$1,
 	 description=$2,
 	 publish_date=$3,
-	 author_id=$4
-	 WHERE id=$5
+	 author_id=$4,
+	 title_normalized=$5,
+	 version=version+1
+	 WHERE id=$6 AND deleted_at IS NULL AND ($7 = 0 OR version=$7)
 	`
 
-	_, err := tx.Exec(c, query,
+	tag, err := tx.Exec(c, query,
 		book.Title,
 		book.Description,
 		book.PublishDate,
 		book.AuthorId,
-		id)
+		domain.NormalizeName(book.Title),
+		id,
+		book.Version)
+	if err != nil {
+		return translateError(domain.EntityBook, err)
+	}
 
-	return err
+	return checkVersionedWrite(c, tx, "books", domain.EntityBook, id, tag)
 }
+
+// bookPatchColumns are the columns PATCH /books/:id may change.
+// title_normalized follows title and is never patched on its own.
+var bookPatchColumns = map[string]bool{
+	"title":            true,
+	"description":      true,
+	"publish_date":     true,
+	"author_id":        true,
+	"title_normalized": true,
+}
+
+func (repository *BookQueryImpl) PatchBook(c context.Context, tx pgx.Tx, id string, version int, changes domain.Changes) error {
+	changes = withNormalized(changes, "title", "title_normalized")
+	query, args, err := buildPatchQuery("books", bookPatchColumns, id, version, changes)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(c, query, args...)
+	if err != nil {
+		return translateError(domain.EntityBook, err)
+	}
+
+	return checkVersionedWrite(c, tx, "books", domain.EntityBook, id, tag)
+}
+
 func (repository *BookQueryImpl) FindByID(c context.Context, tx pgx.Tx, id string) (response.BookResponse, error) {
 	query := `
         SELECT
@@ -75,12 +119,19 @@ func (repository *BookQueryImpl) FindByID(c context.Context, tx pgx.Tx, id strin
 			b.description,
 			b.publish_date,
 			b.author_id,
-			a.name
+			a.name,
+			b.version,
+			b.created_at,
+			b.updated_at,
+			b.created_by,
+			b.updated_by,
+			COALESCE(a.version, 0),
+			COALESCE(a.updated_at, b.updated_at)
         FROM
             books AS b
 		LEFT JOIN authors AS a ON b.author_id = a.id
         WHERE
-            b.id = $1;
+            b.id = $1 AND b.deleted_at IS NULL;
     `
 
 	row := tx.QueryRow(c, query, id)
@@ -92,38 +143,116 @@ func (repository *BookQueryImpl) FindByID(c context.Context, tx pgx.Tx, id strin
 		&data.PublishDate,
 		&data.AuthorId,
 		&data.AuthorName,
+		&data.Version,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+		&data.CreatedBy,
+		&data.UpdatedBy,
+		&data.AuthorVersion,
+		&data.AuthorUpdatedAt,
 	); err != nil {
 		log.Println("Scan", err)
-		return response.BookResponse{}, err
+		return response.BookResponse{}, translateError(domain.EntityBook, err)
 	}
 
 	return data, nil
 }
 
-func (repository *BookQueryImpl) ValidateBookTitle(c context.Context, tx pgx.Tx, title string) (domain.ValidateBookTitle, error) {
-	query := `
-        SELECT
-			b.title
-        FROM
-            books AS b
-        WHERE
-            b.title = $1;
-    `
+// FindAllBook lists the active books changed at or after since, by the
+// later of their and their author's updated_at. With a since it also lists
+// the books deleted since then, positioned at their deleted_at.
+func (repository *BookQueryImpl) FindAllBook(c context.Context, tx pgx.Tx, since time.Time) ([]response.BookResponse, error) {
 
-	row := tx.QueryRow(c, query, title)
-	var data domain.ValidateBookTitle
-	if err := row.Scan(
-		&data.Title,
-	); err != nil && err != pgx.ErrNoRows {
-		log.Println("Scan", err)
-		return domain.ValidateBookTitle{}, err
+	query :=
+		`
+		SELECT
+			b.id,
+			b.title,
+			b.description,
+			b.publish_date,
+			b.author_id,
+			a.name,
+			b.version,
+			b.created_at,
+			b.updated_at,
+			b.created_by,
+			b.updated_by,
+			COALESCE(a.version, 0),
+			COALESCE(a.updated_at, b.updated_at),
+			b.deleted_at,
+			COALESCE(b.deleted_at, GREATEST(b.updated_at, COALESCE(a.updated_at, b.updated_at))) AS changed_at
+		FROM books AS b
+		LEFT JOIN authors AS a ON b.author_id = a.id
+		WHERE (b.deleted_at IS NULL AND GREATEST(b.updated_at, COALESCE(a.updated_at, b.updated_at)) >= $1)
+			OR ($2 AND b.deleted_at >= $1)
+		ORDER BY changed_at, b.id`
+
+	rows, err := tx.Query(c, query, since.UTC(), !since.IsZero())
+	if err != nil {
+		return nil, translateError(domain.EntityBook, err)
 	}
 
-	return data, nil
+	var datas []response.BookResponse
+	for rows.Next() {
+		var data response.BookResponse
+		err := rows.Scan(&data.Id,
+			&data.Title,
+			&data.Description,
+			&data.PublishDate,
+			&data.AuthorId,
+			&data.AuthorName,
+			&data.Version,
+			&data.CreatedAt,
+			&data.UpdatedAt,
+			&data.CreatedBy,
+			&data.UpdatedBy,
+			&data.AuthorVersion,
+			&data.AuthorUpdatedAt,
+			&data.DeletedAt,
+			&data.ChangedAt)
+		if err != nil {
+			return nil,
+				err
+		}
+		datas = append(datas, data)
+	}
+
+	defer rows.Close()
+	return datas, nil
+}
+
+func (repository *BookQueryImpl) DeleteBook(c context.Context, tx pgx.Tx, id string, version int) error {
+
+	query := `UPDATE books SET
+	 deleted_at=timezone('utc', now()),
+	 version=version+1
+	 WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
+
+	tag, err := tx.Exec(c, query, id, version)
+	if err != nil {
+		return translateError(domain.EntityBook, err)
+	}
+
+	return checkVersionedWrite(c, tx, "books", domain.EntityBook, id, tag)
 }
 
-func (repository *BookQueryImpl) FindAllBook(c context.Context, tx pgx.Tx) ([]response.BookResponse, error) {
+func (repository *BookQueryImpl) RestoreBook(c context.Context, tx pgx.Tx, id string) error {
+	query := `UPDATE books SET
+	 deleted_at=NULL,
+	 version=version+1
+	 WHERE id = $1 AND deleted_at IS NOT NULL`
 
+	tag, err := tx.Exec(c, query, id)
+	if err != nil {
+		return translateError(domain.EntityBook, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityBook}
+	}
+	return nil
+}
+
+func (repository *BookQueryImpl) FindDeletedBooks(c context.Context, tx pgx.Tx) ([]response.BookResponse, error) {
 	query :=
 		`
 		SELECT
@@ -132,14 +261,23 @@ func (repository *BookQueryImpl) FindAllBook(c context.Context, tx pgx.Tx) ([]re
 			b.description,
 			b.publish_date,
 			b.author_id,
-			a.name
+			a.name,
+			b.version,
+			b.created_at,
+			b.updated_at,
+			b.created_by,
+			b.updated_by,
+			b.deleted_at
 		FROM books AS b
-		LEFT JOIN authors AS a ON b.author_id = a.id`
+		LEFT JOIN authors AS a ON b.author_id = a.id
+		WHERE b.deleted_at IS NOT NULL
+		ORDER BY b.deleted_at DESC, b.id`
 
 	rows, err := tx.Query(c, query)
 	if err != nil {
-		return nil, err
+		return nil, translateError(domain.EntityBook, err)
 	}
+	defer rows.Close()
 
 	var datas []response.BookResponse
 	for rows.Next() {
@@ -149,26 +287,219 @@ func (repository *BookQueryImpl) FindAllBook(c context.Context, tx pgx.Tx) ([]re
 			&data.Description,
 			&data.PublishDate,
 			&data.AuthorId,
-			&data.AuthorName)
+			&data.AuthorName,
+			&data.Version,
+			&data.CreatedAt,
+			&data.UpdatedAt,
+			&data.CreatedBy,
+			&data.UpdatedBy,
+			&data.DeletedAt)
 		if err != nil {
-			return nil,
-				err
+			return nil, err
 		}
 		datas = append(datas, data)
 	}
+	return datas, rows.Err()
+}
 
+// PurgeBooks hard-deletes books that were moved to the trash before the given
+// time.
+func (repository *BookQueryImpl) PurgeBooks(c context.Context, tx pgx.Tx, before time.Time) (int64, error) {
+	query := `DELETE FROM books WHERE deleted_at < $1`
+
+	tag, err := tx.Exec(c, query, before.UTC())
+	if err != nil {
+		return 0, translateError(domain.EntityBook, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (repository *BookQueryImpl) FindReferencesByAuthor(c context.Context, tx pgx.Tx, authorId string) ([]domain.Reference, error) {
+	query := `
+		SELECT
+			b.id,
+			b.title
+		FROM books AS b
+		WHERE b.author_id = $1 AND b.deleted_at IS NULL
+		ORDER BY b.title, b.id`
+
+	rows, err := tx.Query(c, query, authorId)
+	if err != nil {
+		return nil, translateError(domain.EntityBook, err)
+	}
 	defer rows.Close()
-	return datas, nil
+
+	var datas []domain.Reference
+	for rows.Next() {
+		var data domain.Reference
+		if err := rows.Scan(&data.Id, &data.Title); err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
 }
 
-func (repository *BookQueryImpl) DeleteBook(c context.Context, tx pgx.Tx, id string) error {
+// DeleteBooksByAuthor moves every active book of the author to the trash.
+func (repository *BookQueryImpl) DeleteBooksByAuthor(c context.Context, tx pgx.Tx, authorId string) (int64, error) {
+	query := `UPDATE books SET
+	 deleted_at=timezone('utc', now()),
+	 version=version+1
+	 WHERE author_id = $1 AND deleted_at IS NULL`
+
+	tag, err := tx.Exec(c, query, authorId)
+	if err != nil {
+		return 0, translateError(domain.EntityBook, err)
+	}
+	return tag.RowsAffected(), nil
+}
 
-	query := `DELETE FROM books WHERE id = $1`
+// ReassignBooks moves every active book of one author to another.
+func (repository *BookQueryImpl) ReassignBooks(c context.Context, tx pgx.Tx, fromAuthorId string, toAuthorId string) (int64, error) {
+	query := `UPDATE books SET
+	 author_id=$2,
+	 version=version+1
+	 WHERE author_id = $1 AND deleted_at IS NULL`
 
-	_, err := tx.Exec(c, query, id)
+	tag, err := tx.Exec(c, query, fromAuthorId, toAuthorId)
 	if err != nil {
-		return err
+		return 0, translateError(domain.EntityBook, err)
 	}
+	return tag.RowsAffected(), nil
+}
 
-	return nil
+// bookVersionsQuery selects the versions of book $1 from the history, only
+// version $2 unless it is 0. Each version carries the name its author had
+// when the version was written, valid_to is when the next version was.
+const bookVersionsQuery = `
+	SELECT
+		v.id,
+		v.title,
+		v.description,
+		v.publish_date,
+		v.author_id,
+		COALESCE(an.name, ''),
+		v.version,
+		v.created_at,
+		v.updated_at,
+		v.created_by,
+		v.updated_by,
+		v.deleted_at,
+		v.valid_from,
+		v.valid_to
+	FROM (
+		SELECT h.*, LEAD(h.valid_from) OVER (ORDER BY h.version) AS valid_to
+		FROM books_history AS h
+		WHERE h.id = $1
+	) AS v
+	LEFT JOIN LATERAL (
+		SELECT ah.name
+		FROM authors_history AS ah
+		WHERE ah.id = v.author_id AND ah.valid_from <= v.valid_from
+		ORDER BY ah.version DESC
+		LIMIT 1
+	) AS an ON true
+	WHERE ($2 = 0 OR v.version = $2)
+	ORDER BY v.version`
+
+func scanBookVersion(row pgx.Row) (domain.BookVersion, error) {
+	var data domain.BookVersion
+	err := row.Scan(
+		&data.Book.Id,
+		&data.Book.Title,
+		&data.Book.Description,
+		&data.Book.PublishDate,
+		&data.Book.AuthorId,
+		&data.Book.AuthorName,
+		&data.Book.Version,
+		&data.Book.CreatedAt,
+		&data.Book.UpdatedAt,
+		&data.Book.CreatedBy,
+		&data.Book.UpdatedBy,
+		&data.Book.DeletedAt,
+		&data.ValidFrom,
+		&data.ValidTo,
+	)
+	return data, err
+}
+
+// FindBookVersions returns every recorded version of the book, oldest first,
+// including the versions written while it was in the trash.
+func (repository *BookQueryImpl) FindBookVersions(c context.Context, tx pgx.Tx, id string) ([]domain.BookVersion, error) {
+	rows, err := tx.Query(c, bookVersionsQuery, id, 0)
+	if err != nil {
+		return nil, translateError(domain.EntityBook, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.BookVersion
+	for rows.Next() {
+		data, err := scanBookVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *BookQueryImpl) FindBookVersion(c context.Context, tx pgx.Tx, id string, version int) (domain.BookVersion, error) {
+	data, err := scanBookVersion(tx.QueryRow(c, bookVersionsQuery, id, version))
+	if err != nil {
+		return domain.BookVersion{}, translateError(domain.EntityBookVersion, err)
+	}
+	return data, nil
+}
+
+// FindBookAsOf returns the book as it was at asOf, with the name its author
+// had at that time. A book that did not exist yet or was in the trash at
+// that time is not found.
+func (repository *BookQueryImpl) FindBookAsOf(c context.Context, tx pgx.Tx, id string, asOf time.Time) (response.BookResponse, error) {
+	query := `
+		SELECT
+			h.id,
+			h.title,
+			h.description,
+			h.publish_date,
+			h.author_id,
+			COALESCE(an.name, ''),
+			h.version,
+			h.created_at,
+			h.updated_at,
+			h.created_by,
+			h.updated_by,
+			h.deleted_at
+		FROM books_history AS h
+		LEFT JOIN LATERAL (
+			SELECT ah.name
+			FROM authors_history AS ah
+			WHERE ah.id = h.author_id AND ah.valid_from <= $2
+			ORDER BY ah.version DESC
+			LIMIT 1
+		) AS an ON true
+		WHERE h.id = $1 AND h.valid_from <= $2
+		ORDER BY h.version DESC
+		LIMIT 1`
+
+	var data response.BookResponse
+	if err := tx.QueryRow(c, query, id, asOf.UTC()).Scan(
+		&data.Id,
+		&data.Title,
+		&data.Description,
+		&data.PublishDate,
+		&data.AuthorId,
+		&data.AuthorName,
+		&data.Version,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+		&data.CreatedBy,
+		&data.UpdatedBy,
+		&data.DeletedAt,
+	); err != nil {
+		return response.BookResponse{}, translateError(domain.EntityBook, err)
+	}
+	if data.DeletedAt != nil {
+		return response.BookResponse{}, &domain.NotFoundError{Entity: domain.EntityBook}
+	}
+	return data, nil
 }
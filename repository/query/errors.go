@@ -0,0 +1,78 @@
+package query
+
+import (
+	"errors"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	sqlStateUniqueViolation     = "23505"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateNotNullViolation    = "23502"
+	sqlStateStringTooLong       = "22001"
+	sqlStateInvalidDatetime     = "22007"
+	sqlStateDatetimeOverflow    = "22008"
+	sqlStateInvalidText         = "22P02"
+)
+
+// constraintFields maps database constraint names to the request field they guard.
+var constraintFields = map[string]string{
+	"authors_name_key":                  "name",
+	"authors_name_normalized_key":       "name",
+	"books_author_title_normalized_key": "title",
+	"fk_author":                         "author_id",
+	"users_email_normalized_key":        "email",
+	"roles_pkey":                        "name",
+	"fk_role_permissions_permission":    "permissions",
+	"fk_user_roles_role":                "roles",
+	"fk_user_roles_user":                "user_id",
+	"api_keys_prefix_key":               "prefix",
+}
+
+// translateError turns pgx and Postgres errors into domain errors of the given
+// entity. Errors it does not recognise are returned unchanged.
+func translateError(entity string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &domain.NotFoundError{Entity: entity, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case sqlStateUniqueViolation:
+		return &domain.ConflictError{
+			Entity:     entity,
+			Field:      constraintFields[pgErr.ConstraintName],
+			Constraint: pgErr.ConstraintName,
+			Err:        err,
+		}
+	case sqlStateForeignKeyViolation:
+		return &domain.InvalidReferenceError{
+			Entity:     entity,
+			Field:      constraintFields[pgErr.ConstraintName],
+			Constraint: pgErr.ConstraintName,
+			Err:        err,
+		}
+	case sqlStateNotNullViolation:
+		return &domain.InvalidInputError{Field: pgErr.ColumnName, Reason: "must be filled", Err: err}
+	case sqlStateStringTooLong:
+		return &domain.InvalidInputError{Field: pgErr.ColumnName, Reason: "is too long", Err: err}
+	case sqlStateInvalidDatetime, sqlStateDatetimeOverflow:
+		return &domain.InvalidInputError{Field: pgErr.ColumnName, Reason: "must be a valid date", Err: err}
+	case sqlStateInvalidText:
+		return &domain.InvalidInputError{Reason: "Invalid input syntax", Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type OutboxQuery interface {
+	CreateOutboxEvent(c context.Context, tx pgx.Tx, event domain.OutboxEvent) error
+	LockOutbox(c context.Context, tx pgx.Tx) (bool, error)
+	FindPendingOutboxEvents(c context.Context, tx pgx.Tx, limit int) ([]domain.OutboxEvent, error)
+	MarkOutboxEventsPublished(c context.Context, tx pgx.Tx, ids []int64) error
+	RecordOutboxFailure(c context.Context, tx pgx.Tx, id int64, reason string, maxAttempts int) (bool, error)
+	DeletePublishedOutboxEvents(c context.Context, tx pgx.Tx, before time.Time) (int64, error)
+}
+
+type OutboxQueryImpl struct {
+}
+
+func NewOutbox() OutboxQuery {
+	return &OutboxQueryImpl{}
+}
+
+func (repository *OutboxQueryImpl) CreateOutboxEvent(c context.Context, tx pgx.Tx, event domain.OutboxEvent) error {
+	query := `INSERT INTO outbox
+	(
+		"event_type",
+		"aggregate_type",
+		"aggregate_id",
+		"payload",
+		"actor_id",
+		"request_id"
+	)
+	VALUES ($1,$2,$3,$4,$5,$6)`
+
+	_, err := tx.Exec(c, query,
+		event.Type,
+		event.AggregateType,
+		event.AggregateId,
+		event.Payload,
+		event.ActorId,
+		event.RequestId)
+
+	return translateError(domain.EntityOutboxEvent, err)
+}
+
+// LockOutbox takes the relay lock for the rest of the transaction and
+// reports whether it got it. Only one relay publishes at a time, which keeps
+// the events of an entity in order across instances of the API.
+func (repository *OutboxQueryImpl) LockOutbox(c context.Context, tx pgx.Tx) (bool, error) {
+	var locked bool
+	if err := tx.QueryRow(c, `SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay'))`).Scan(&locked); err != nil {
+		return false, translateError(domain.EntityOutboxEvent, err)
+	}
+	return locked, nil
+}
+
+// FindPendingOutboxEvents returns up to limit events that are due, oldest
+// first. An event is left out while an earlier event of its entity is still
+// pending after a failed attempt, so entities that fail do not fill the batch
+// and the others keep flowing.
+func (repository *OutboxQueryImpl) FindPendingOutboxEvents(c context.Context, tx pgx.Tx, limit int) ([]domain.OutboxEvent, error) {
+	query := `
+		SELECT
+			o.id,
+			o.event_type,
+			o.aggregate_type,
+			o.aggregate_id,
+			o.payload,
+			o.actor_id,
+			o.request_id,
+			o.created_at,
+			o.attempts
+		FROM outbox AS o
+		WHERE o.published_at IS NULL AND o.dead_lettered_at IS NULL
+			AND (o.next_attempt_at IS NULL OR o.next_attempt_at <= timezone('utc', now()))
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox AS p
+				WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id
+					AND p.published_at IS NULL AND p.dead_lettered_at IS NULL
+					AND p.id < o.id AND p.attempts > 0
+			)
+		ORDER BY o.id
+		LIMIT $1`
+
+	rows, err := tx.Query(c, query, limit)
+	if err != nil {
+		return nil, translateError(domain.EntityOutboxEvent, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.OutboxEvent
+	for rows.Next() {
+		var data domain.OutboxEvent
+		if err := rows.Scan(
+			&data.Id,
+			&data.Type,
+			&data.AggregateType,
+			&data.AggregateId,
+			&data.Payload,
+			&data.ActorId,
+			&data.RequestId,
+			&data.CreatedAt,
+			&data.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *OutboxQueryImpl) MarkOutboxEventsPublished(c context.Context, tx pgx.Tx, ids []int64) error {
+	query := `UPDATE outbox SET
+	 published_at=timezone('utc', now()),
+	 last_error=NULL
+	 WHERE id = ANY($1)`
+
+	_, err := tx.Exec(c, query, ids)
+	return translateError(domain.EntityOutboxEvent, err)
+}
+
+// RecordOutboxFailure counts a failed attempt. The event is retried after a
+// delay doubling with every attempt, up to an hour, and dead lettered once it
+// failed maxAttempts times; it reports whether that happened.
+func (repository *OutboxQueryImpl) RecordOutboxFailure(c context.Context, tx pgx.Tx, id int64, reason string, maxAttempts int) (bool, error) {
+	query := `UPDATE outbox SET
+	 attempts=attempts+1,
+	 last_error=$2,
+	 next_attempt_at=timezone('utc', now()) + LEAST(interval '1 second' * power(2, attempts), interval '1 hour'),
+	 dead_lettered_at=CASE WHEN attempts+1 >= $3 THEN timezone('utc', now()) END
+	 WHERE id = $1
+	 RETURNING dead_lettered_at IS NOT NULL`
+
+	var deadLettered bool
+	if err := tx.QueryRow(c, query, id, reason, maxAttempts).Scan(&deadLettered); err != nil {
+		return false, translateError(domain.EntityOutboxEvent, err)
+	}
+	return deadLettered, nil
+}
+
+// DeletePublishedOutboxEvents removes events published before the given
+// time. Pending events are never removed.
+func (repository *OutboxQueryImpl) DeletePublishedOutboxEvents(c context.Context, tx pgx.Tx, before time.Time) (int64, error) {
+	query := `DELETE FROM outbox WHERE published_at < $1`
+
+	tag, err := tx.Exec(c, query, before.UTC())
+	if err != nil {
+		return 0, translateError(domain.EntityOutboxEvent, err)
+	}
+	return tag.RowsAffected(), nil
+}
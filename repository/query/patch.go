@@ -0,0 +1,55 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"test-backend-altech/model/domain"
+)
+
+// buildPatchQuery builds an UPDATE of table that only sets the changed
+// columns and bumps the version. Columns missing from allowed are rejected so
+// callers can never write to columns such as id or created_at. Rows in the
+// trash are never matched. A version of 0 skips the optimistic concurrency
+// check.
+func buildPatchQuery(table string, allowed map[string]bool, id string, version int, changes domain.Changes) (string, []interface{}, error) {
+	columns := make([]string, 0, len(changes))
+	for column := range changes {
+		if !allowed[column] {
+			return "", nil, fmt.Errorf("column %s of %s can not be patched", column, table)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	sets := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s=$%d", column, i+1))
+		args = append(args, changes[column])
+	}
+	sets = append(sets, "version=version+1")
+	args = append(args, id, version)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%d AND deleted_at IS NULL AND ($%d = 0 OR version=$%d)",
+		table, strings.Join(sets, ", "), len(args)-1, len(args), len(args))
+	return query, args, nil
+}
+
+// withNormalized returns the changes with target set to the normalized form
+// of the string in column when the changes set column. The changes passed in
+// are left as they are.
+func withNormalized(changes domain.Changes, column string, target string) domain.Changes {
+	value, ok := changes[column].(string)
+	if !ok {
+		return changes
+	}
+
+	normalized := make(domain.Changes, len(changes)+1)
+	for key, change := range changes {
+		normalized[key] = change
+	}
+	normalized[target] = domain.NormalizeName(value)
+	return normalized
+}
@@ -0,0 +1,161 @@
+package query
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type RoleQuery interface {
+	FindAllRoles(c context.Context, tx pgx.Tx) ([]domain.Role, error)
+	FindRole(c context.Context, tx pgx.Tx, name string) (domain.Role, error)
+	CreateRole(c context.Context, tx pgx.Tx, role domain.Role) error
+	DeleteRole(c context.Context, tx pgx.Tx, name string) error
+	SetRolePermissions(c context.Context, tx pgx.Tx, name string, permissions []string) error
+	FindAllPermissions(c context.Context, tx pgx.Tx) ([]domain.Permission, error)
+	FindUserRoles(c context.Context, tx pgx.Tx, userId string) ([]string, error)
+	SetUserRoles(c context.Context, tx pgx.Tx, userId string, roles []string) error
+	FindUserPermissions(c context.Context, tx pgx.Tx, userId string) ([]string, error)
+}
+
+type RoleQueryImpl struct {
+}
+
+func NewRole() RoleQuery {
+	return &RoleQueryImpl{}
+}
+
+const selectRoles = `
+		SELECT
+			r.name,
+			r.description,
+			COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}'),
+			r.created_at
+		FROM roles AS r
+		LEFT JOIN role_permissions AS rp ON rp.role = r.name`
+
+func (repository *RoleQueryImpl) FindAllRoles(c context.Context, tx pgx.Tx) ([]domain.Role, error) {
+	query := selectRoles + `
+		GROUP BY r.name
+		ORDER BY r.name`
+
+	rows, err := tx.Query(c, query)
+	if err != nil {
+		return nil, translateError(domain.EntityRole, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.Role
+	for rows.Next() {
+		var data domain.Role
+		if err := rows.Scan(&data.Name, &data.Description, &data.Permissions, &data.CreatedAt); err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *RoleQueryImpl) FindRole(c context.Context, tx pgx.Tx, name string) (domain.Role, error) {
+	query := selectRoles + `
+		WHERE r.name = $1
+		GROUP BY r.name`
+
+	var data domain.Role
+	if err := tx.QueryRow(c, query, name).Scan(&data.Name, &data.Description, &data.Permissions, &data.CreatedAt); err != nil {
+		return domain.Role{}, translateError(domain.EntityRole, err)
+	}
+	return data, nil
+}
+
+func (repository *RoleQueryImpl) CreateRole(c context.Context, tx pgx.Tx, role domain.Role) error {
+	query := `INSERT INTO roles ("name", "description") VALUES ($1,$2)`
+
+	_, err := tx.Exec(c, query, role.Name, role.Description)
+	return translateError(domain.EntityRole, err)
+}
+
+func (repository *RoleQueryImpl) DeleteRole(c context.Context, tx pgx.Tx, name string) error {
+	tag, err := tx.Exec(c, `DELETE FROM roles WHERE name = $1`, name)
+	if err != nil {
+		return translateError(domain.EntityRole, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityRole}
+	}
+	return nil
+}
+
+// SetRolePermissions replaces the permissions of a role. Unknown permissions
+// are reported by the foreign key.
+func (repository *RoleQueryImpl) SetRolePermissions(c context.Context, tx pgx.Tx, name string, permissions []string) error {
+	if _, err := tx.Exec(c, `DELETE FROM role_permissions WHERE role = $1`, name); err != nil {
+		return translateError(domain.EntityRole, err)
+	}
+
+	query := `INSERT INTO role_permissions (role, permission)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING`
+	_, err := tx.Exec(c, query, name, permissions)
+	return translateError(domain.EntityRole, err)
+}
+
+func (repository *RoleQueryImpl) FindAllPermissions(c context.Context, tx pgx.Tx) ([]domain.Permission, error) {
+	rows, err := tx.Query(c, `SELECT p.name, p.description FROM permissions AS p ORDER BY p.name`)
+	if err != nil {
+		return nil, translateError(domain.EntityRole, err)
+	}
+	defer rows.Close()
+
+	var datas []domain.Permission
+	for rows.Next() {
+		var data domain.Permission
+		if err := rows.Scan(&data.Name, &data.Description); err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+	return datas, rows.Err()
+}
+
+func (repository *RoleQueryImpl) FindUserRoles(c context.Context, tx pgx.Tx, userId string) ([]string, error) {
+	query := `SELECT COALESCE(array_agg(ur.role ORDER BY ur.role), '{}') FROM user_roles AS ur WHERE ur.user_id = $1`
+
+	var roles []string
+	if err := tx.QueryRow(c, query, userId).Scan(&roles); err != nil {
+		return nil, translateError(domain.EntityUser, err)
+	}
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of a user. Unknown roles are reported by
+// the foreign key.
+func (repository *RoleQueryImpl) SetUserRoles(c context.Context, tx pgx.Tx, userId string, roles []string) error {
+	if _, err := tx.Exec(c, `DELETE FROM user_roles WHERE user_id = $1`, userId); err != nil {
+		return translateError(domain.EntityUser, err)
+	}
+
+	query := `INSERT INTO user_roles (user_id, role)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING`
+	_, err := tx.Exec(c, query, userId, roles)
+	return translateError(domain.EntityUser, err)
+}
+
+// FindUserPermissions returns every permission granted to the user through
+// any of its roles.
+func (repository *RoleQueryImpl) FindUserPermissions(c context.Context, tx pgx.Tx, userId string) ([]string, error) {
+	query := `
+		SELECT COALESCE(array_agg(DISTINCT rp.permission), '{}')
+		FROM user_roles AS ur
+		JOIN role_permissions AS rp ON rp.role = ur.role
+		WHERE ur.user_id = $1`
+
+	var permissions []string
+	if err := tx.QueryRow(c, query, userId).Scan(&permissions); err != nil {
+		return nil, translateError(domain.EntityUser, err)
+	}
+	return permissions, nil
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"context"
+	"log"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type UserQuery interface {
+	CreateUser(c context.Context, tx pgx.Tx, user domain.User) error
+	FindByID(c context.Context, tx pgx.Tx, id string) (domain.User, error)
+	FindByEmail(c context.Context, tx pgx.Tx, email string) (domain.User, error)
+	FindByOIDCSubject(c context.Context, tx pgx.Tx, issuer string, subject string) (domain.User, error)
+	LinkOIDCSubject(c context.Context, tx pgx.Tx, id string, issuer string, subject string) error
+	CountUsers(c context.Context, tx pgx.Tx) (int64, error)
+}
+
+type UserQueryImpl struct {
+}
+
+func NewUser() UserQuery {
+	return &UserQueryImpl{}
+}
+
+func (repository *UserQueryImpl) CreateUser(c context.Context, tx pgx.Tx, user domain.User) error {
+
+	query := `INSERT INTO users 
+	(
+		"id", 
+		"email",
+		"name",
+		"password_hash",
+		"oidc_issuer",
+		"oidc_subject"
+	) 
+	VALUES ($1,$2,$3,$4,$5,$6)`
+
+	_, err := tx.Exec(c, query,
+		user.Id,
+		user.Email,
+		user.Name,
+		user.PasswordHash,
+		user.OIDCIssuer,
+		user.OIDCSubject)
+
+	return translateError(domain.EntityUser, err)
+}
+
+func (repository *UserQueryImpl) FindByID(c context.Context, tx pgx.Tx, id string) (domain.User, error) {
+	query := `
+        SELECT
+            u.id,
+			u.email,
+			u.name,
+			u.password_hash,
+			u.oidc_issuer,
+			u.oidc_subject,
+			u.version,
+			u.created_at,
+			u.updated_at
+        FROM
+            users AS u
+        WHERE
+            u.id = $1;
+    `
+
+	return scanUser(tx.QueryRow(c, query, id))
+}
+
+// FindByEmail looks a user up by email, ignoring case and surrounding spaces.
+func (repository *UserQueryImpl) FindByEmail(c context.Context, tx pgx.Tx, email string) (domain.User, error) {
+	query := `
+        SELECT
+            u.id,
+			u.email,
+			u.name,
+			u.password_hash,
+			u.oidc_issuer,
+			u.oidc_subject,
+			u.version,
+			u.created_at,
+			u.updated_at
+        FROM
+            users AS u
+        WHERE
+            lower(btrim(u.email)) = lower(btrim($1));
+    `
+
+	return scanUser(tx.QueryRow(c, query, email))
+}
+
+func (repository *UserQueryImpl) FindByOIDCSubject(c context.Context, tx pgx.Tx, issuer string, subject string) (domain.User, error) {
+	query := `
+        SELECT
+            u.id,
+			u.email,
+			u.name,
+			u.password_hash,
+			u.oidc_issuer,
+			u.oidc_subject,
+			u.version,
+			u.created_at,
+			u.updated_at
+        FROM
+            users AS u
+        WHERE
+            u.oidc_issuer = $1 AND u.oidc_subject = $2;
+    `
+
+	return scanUser(tx.QueryRow(c, query, issuer, subject))
+}
+
+// LinkOIDCSubject records the provider identity of a user that has none yet.
+func (repository *UserQueryImpl) LinkOIDCSubject(c context.Context, tx pgx.Tx, id string, issuer string, subject string) error {
+	query := `UPDATE users SET
+	 oidc_issuer=$2,
+	 oidc_subject=$3,
+	 version=version+1
+	 WHERE id = $1 AND oidc_subject IS NULL`
+
+	tag, err := tx.Exec(c, query, id, issuer, subject)
+	if err != nil {
+		return translateError(domain.EntityUser, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return &domain.NotFoundError{Entity: domain.EntityUser}
+	}
+	return nil
+}
+
+func (repository *UserQueryImpl) CountUsers(c context.Context, tx pgx.Tx) (int64, error) {
+	var count int64
+	if err := tx.QueryRow(c, `SELECT count(*) FROM users`).Scan(&count); err != nil {
+		return 0, translateError(domain.EntityUser, err)
+	}
+	return count, nil
+}
+
+func scanUser(row pgx.Row) (domain.User, error) {
+	var data domain.User
+	if err := row.Scan(
+		&data.Id,
+		&data.Email,
+		&data.Name,
+		&data.PasswordHash,
+		&data.OIDCIssuer,
+		&data.OIDCSubject,
+		&data.Version,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+	); err != nil {
+		log.Println("Scan", err)
+		return domain.User{}, translateError(domain.EntityUser, err)
+	}
+	return data, nil
+}
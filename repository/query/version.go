@@ -0,0 +1,26 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"test-backend-altech/model/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// checkVersionedWrite explains why a versioned UPDATE or DELETE of id touched
+// no rows: the row is either gone, in the trash or stored at another version.
+func checkVersionedWrite(c context.Context, tx pgx.Tx, table string, entity string, id string, tag pgconn.CommandTag) error {
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var version int
+	query := fmt.Sprintf("SELECT version FROM %s WHERE id = $1 AND deleted_at IS NULL", table)
+	if err := tx.QueryRow(c, query, id).Scan(&version); err != nil {
+		return translateError(entity, err)
+	}
+	return &domain.PreconditionFailedError{Entity: entity}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository/query"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type roleRepository struct {
+	db        Store
+	RoleQuery query.RoleQuery
+}
+
+type RoleRepository interface {
+	FindAllRoles(c context.Context) ([]domain.Role, error)
+	FindRole(c context.Context, name string) (domain.Role, error)
+	CreateRole(c context.Context, role domain.Role) error
+	DeleteRole(c context.Context, name string) error
+	SetRolePermissions(c context.Context, name string, permissions []string) error
+	FindAllPermissions(c context.Context) ([]domain.Permission, error)
+	FindUserRoles(c context.Context, userId string) ([]string, error)
+	SetUserRoles(c context.Context, userId string, roles []string) error
+	FindUserPermissions(c context.Context, userId string) ([]string, error)
+}
+
+func NewRoleRepository(db Store, q query.RoleQuery) RoleRepository {
+	return &roleRepository{
+		db:        db,
+		RoleQuery: q,
+	}
+}
+
+func (r *roleRepository) FindAllRoles(c context.Context) ([]domain.Role, error) {
+	var err error
+	var roles []domain.Role
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		roles, err = r.RoleQuery.FindAllRoles(c, tx)
+		return err
+	})
+
+	return roles, err
+}
+
+func (r *roleRepository) FindRole(c context.Context, name string) (domain.Role, error) {
+	var err error
+	var role domain.Role
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		role, err = r.RoleQuery.FindRole(c, tx, name)
+		return err
+	})
+
+	return role, err
+}
+
+func (r *roleRepository) CreateRole(c context.Context, role domain.Role) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.RoleQuery.CreateRole(c, tx, role)
+		return err
+	})
+
+	return err
+}
+
+func (r *roleRepository) DeleteRole(c context.Context, name string) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.RoleQuery.DeleteRole(c, tx, name)
+		return err
+	})
+
+	return err
+}
+
+func (r *roleRepository) SetRolePermissions(c context.Context, name string, permissions []string) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.RoleQuery.SetRolePermissions(c, tx, name, permissions)
+		return err
+	})
+
+	return err
+}
+
+func (r *roleRepository) FindAllPermissions(c context.Context) ([]domain.Permission, error) {
+	var err error
+	var permissions []domain.Permission
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		permissions, err = r.RoleQuery.FindAllPermissions(c, tx)
+		return err
+	})
+
+	return permissions, err
+}
+
+func (r *roleRepository) FindUserRoles(c context.Context, userId string) ([]string, error) {
+	var err error
+	var roles []string
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		roles, err = r.RoleQuery.FindUserRoles(c, tx, userId)
+		return err
+	})
+
+	return roles, err
+}
+
+func (r *roleRepository) SetUserRoles(c context.Context, userId string, roles []string) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.RoleQuery.SetUserRoles(c, tx, userId, roles)
+		return err
+	})
+
+	return err
+}
+
+func (r *roleRepository) FindUserPermissions(c context.Context, userId string) ([]string, error) {
+	var err error
+	var permissions []string
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		permissions, err = r.RoleQuery.FindUserPermissions(c, tx, userId)
+		return err
+	})
+
+	return permissions, err
+}
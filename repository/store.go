@@ -2,50 +2,181 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
 	"time"
 
 	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
 type Store interface {
 	WithTransaction(ctx context.Context, fn func(pgx.Tx) error) error
+	WithTransactionOptions(ctx context.Context, opts pgx.TxOptions, fn func(pgx.Tx) error) error
 	WithoutTransaction(ctx context.Context, fn func(*pgxpool.Pool) error) error
+	Metrics() TxMetrics
+}
+
+// TxMetrics is a snapshot of the transaction counters kept by the store.
+type TxMetrics struct {
+	Started               int64 `json:"started"`
+	Committed             int64 `json:"committed"`
+	RolledBack            int64 `json:"rolled_back"`
+	Retried               int64 `json:"retried"`
+	SerializationFailures int64 `json:"serialization_failures"`
+	Deadlocks             int64 `json:"deadlocks"`
+	RetryBudgetExhausted  int64 `json:"retry_budget_exhausted"`
+}
+
+type txCounters struct {
+	started               atomic.Int64
+	committed             atomic.Int64
+	rolledBack            atomic.Int64
+	retried               atomic.Int64
+	serializationFailures atomic.Int64
+	deadlocks             atomic.Int64
+	retryBudgetExhausted  atomic.Int64
 }
 
 type StoreImpl struct {
-	Db *pgxpool.Pool
+	Db      *pgxpool.Pool
+	Retry   config.TxRetryConfig
+	metrics txCounters
 }
 
 func NewStore(db *pgxpool.Pool) Store {
 	return &StoreImpl{
-		Db: db,
+		Db:    db,
+		Retry: config.NewTxRetryConfig(),
 	}
 }
 
-// This function is used for starting transaction.
+// This function is used for starting transaction with the default isolation level.
 func (r *StoreImpl) WithTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
+	return r.WithTransactionOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// This function is used for starting transaction with the given options.
+// When the transaction fails with a serialization failure or a deadlock the
+// whole function is run again in a fresh transaction, so fn must not have
+// side effects outside of the transaction.
+// If ctx already carries a transaction started by a UnitOfWork, fn joins it
+// and the options are ignored; committing and retrying is left to the owner.
+func (r *StoreImpl) WithTransactionOptions(ctx context.Context, opts pgx.TxOptions, fn func(pgx.Tx) error) error {
+	if tx, ok := txFromContext(ctx); ok {
+		return fn(tx)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = r.runTransaction(ctx, opts, fn)
+		if !isRetryable(err) {
+			return err
+		}
+
+		r.countRetryable(err)
+		if attempt >= r.Retry.MaxAttempts {
+			r.metrics.retryBudgetExhausted.Add(1)
+			return err
+		}
+
+		r.metrics.retried.Add(1)
+		if waitErr := r.backoff(ctx, attempt); waitErr != nil {
+			return err
+		}
+	}
+}
+
+func (r *StoreImpl) runTransaction(ctx context.Context, opts pgx.TxOptions, fn func(pgx.Tx) error) error {
 	// context.WithTimeout is used for setting a timeout for this operation.
 	c, cancel := context.WithTimeout(context.Background(), time.Duration(config.TimeOutDuration)*time.Second)
 	defer cancel()
 
 	// begin transaction for this operation.
-	tx, err := r.Db.Begin(c)
+	tx, err := r.Db.BeginTx(c, opts)
 	if err != nil {
 		return err
 	}
+	r.metrics.started.Add(1)
+
+	// the caller is stored for the set_record_actor trigger, which fills
+	// created_by and updated_by. It only lives as long as the transaction.
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		if _, err := tx.Exec(c, `SELECT set_config('app.user_id', $1, true)`, principal.UserId); err != nil {
+			_ = tx.Rollback(ctx)
+			r.metrics.rolledBack.Add(1)
+			return err
+		}
+	}
 
 	// run fungtion with transaction db.
 	// if funtion return error then rollback and return error
 	if err := fn(tx); err != nil {
 		_ = tx.Rollback(ctx)
+		r.metrics.rolledBack.Add(1)
 		return err
 	}
 
 	// commit transaction
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		r.metrics.rolledBack.Add(1)
+		return err
+	}
+	r.metrics.committed.Add(1)
+	return nil
+}
+
+// backoff sleeps for a random duration between zero and the exponential
+// delay of the given attempt, capped at the configured maximum.
+func (r *StoreImpl) backoff(ctx context.Context, attempt int) error {
+	delay := r.Retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > r.Retry.MaxDelay {
+		delay = r.Retry.MaxDelay
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(rand.N(delay) + 1)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *StoreImpl) countRetryable(err error) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return
+	}
+	switch pgErr.Code {
+	case sqlStateSerializationFailure:
+		r.metrics.serializationFailures.Add(1)
+	case sqlStateDeadlockDetected:
+		r.metrics.deadlocks.Add(1)
+	}
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
 }
 
 // This function is used for query without transactions.
@@ -57,3 +188,16 @@ func (r *StoreImpl) WithoutTransaction(ctx context.Context, fn func(*pgxpool.Poo
 
 	return nil
 }
+
+// Metrics returns a snapshot of the transaction counters.
+func (r *StoreImpl) Metrics() TxMetrics {
+	return TxMetrics{
+		Started:               r.metrics.started.Load(),
+		Committed:             r.metrics.committed.Load(),
+		RolledBack:            r.metrics.rolledBack.Load(),
+		Retried:               r.metrics.retried.Load(),
+		SerializationFailures: r.metrics.serializationFailures.Load(),
+		Deadlocks:             r.metrics.deadlocks.Load(),
+		RetryBudgetExhausted:  r.metrics.retryBudgetExhausted.Load(),
+	}
+}
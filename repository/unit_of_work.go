@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SerializableTx runs a unit of work with SERIALIZABLE isolation, which makes
+// check-then-write flows safe against concurrent requests.
+var SerializableTx = pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+// ErrNoUnitOfWork is returned by writes that are only correct as part of a
+// larger change when they are called outside of a unit of work.
+var ErrNoUnitOfWork = errors.New("repository: no unit of work in context")
+
+type txContextKey struct{}
+
+// UnitOfWork runs several repository calls inside one shared transaction.
+// Repositories called with the context passed to fn join that transaction
+// instead of starting their own.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error
+}
+
+type unitOfWork struct {
+	db Store
+}
+
+func NewUnitOfWork(db Store) UnitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.DoWithOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+func (u *unitOfWork) DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return u.db.WithTransactionOptions(ctx, opts, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+}
+
+// InUnitOfWork reports whether ctx belongs to a unit of work, so repository
+// calls with it join its transaction.
+func InUnitOfWork(ctx context.Context) bool {
+	_, ok := txFromContext(ctx)
+	return ok
+}
+
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(pgx.Tx)
+	return tx, ok
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository/query"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type userRepository struct {
+	db        Store
+	UserQuery query.UserQuery
+}
+
+type UserRepository interface {
+	CreateUser(c context.Context, user domain.User) error
+	FindByID(c context.Context, id string) (domain.User, error)
+	FindByEmail(c context.Context, email string) (domain.User, error)
+	FindByOIDCSubject(c context.Context, issuer string, subject string) (domain.User, error)
+	LinkOIDCSubject(c context.Context, id string, issuer string, subject string) error
+	CountUsers(c context.Context) (int64, error)
+}
+
+func NewUserRepository(db Store, q query.UserQuery) UserRepository {
+	return &userRepository{
+		db:        db,
+		UserQuery: q,
+	}
+}
+
+func (r *userRepository) CreateUser(c context.Context, user domain.User) error {
+	var err error
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		err = r.UserQuery.CreateUser(c, tx, user)
+		return err
+	})
+
+	return err
+}
+
+func (r *userRepository) FindByID(c context.Context, id string) (domain.User, error) {
+	var err error
+	var user domain.User
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		user, err = r.UserQuery.FindByID(c, tx, id)
+		return err
+	})
+
+	return user, err
+}
+
+func (r *userRepository) FindByEmail(c context.Context, email string) (domain.User, error) {
+	var err error
+	var user domain.User
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		user, err = r.UserQuery.FindByEmail(c, tx, email)
+		return err
+	})
+
+	return user, err
+}
+
+func (r *userRepository) CountUsers(c context.Context) (int64, error) {
+	var err error
+	var count int64
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		count, err = r.UserQuery.CountUsers(c, tx)
+		return err
+	})
+
+	return count, err
+}
+
+func (r *userRepository) FindByOIDCSubject(c context.Context, issuer string, subject string) (domain.User, error) {
+	var err error
+	var user domain.User
+
+	err = r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		user, err = r.UserQuery.FindByOIDCSubject(c, tx, issuer, subject)
+		return err
+	})
+
+	return user, err
+}
+
+func (r *userRepository) LinkOIDCSubject(c context.Context, id string, issuer string, subject string) error {
+	return r.db.WithTransaction(c, func(tx pgx.Tx) error {
+		return r.UserQuery.LinkOIDCSubject(c, tx, id, issuer, subject)
+	})
+}
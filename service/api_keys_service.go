@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+)
+
+// An API key reads "ak_<prefix>.<secret>". The prefix is stored as is so a
+// key can be looked up and recognised in listings, only a hash of the secret
+// is stored. The secret has 256 bits of entropy, so a plain SHA-256 is enough.
+const (
+	apiKeyPrefix      = "ak_"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+)
+
+type ApiKeyService interface {
+	CreateApiKey(ctx context.Context, userId string, request request.ApiKeyRequest) (response.CreatedApiKeyResponse, error)
+	FindApiKeys(ctx context.Context, userId string) ([]response.ApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, userId string, id string) (response.ApiKeyResponse, error)
+	Authenticate(ctx context.Context, key string) (domain.Principal, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepository repository.ApiKeyRepository
+	userRepository   repository.UserRepository
+	roleRepository   repository.RoleRepository
+}
+
+func NewApiKeyService(apiKeyRepository repository.ApiKeyRepository, userRepository repository.UserRepository, roleRepository repository.RoleRepository) ApiKeyService {
+	return &apiKeyService{
+		apiKeyRepository: apiKeyRepository,
+		userRepository:   userRepository,
+		roleRepository:   roleRepository,
+	}
+}
+
+// CreateApiKey creates a key for userId. The full key is only part of this
+// response.
+func (s *apiKeyService) CreateApiKey(ctx context.Context, userId string, request request.ApiKeyRequest) (response.CreatedApiKeyResponse, error) {
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return response.CreatedApiKeyResponse{}, &domain.InvalidInputError{Field: "expires_at", Reason: "must be in the future"}
+	}
+
+	prefix, err := randomString(apiKeyPrefixBytes, hex.EncodeToString)
+	if err != nil {
+		return response.CreatedApiKeyResponse{}, err
+	}
+	secret, err := randomString(apiKeySecretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return response.CreatedApiKeyResponse{}, err
+	}
+
+	key := domain.ApiKey{
+		UserId:     userId,
+		Name:       request.Name,
+		Prefix:     apiKeyPrefix + prefix,
+		SecretHash: hashApiKeySecret(secret),
+		Scopes:     request.Scopes,
+	}
+	if request.ExpiresAt != nil {
+		expiresAt := request.ExpiresAt.UTC()
+		key.ExpiresAt = &expiresAt
+	}
+	key.GenerateID()
+
+	created, err := s.apiKeyRepository.CreateApiKey(ctx, key)
+	if err != nil {
+		return response.CreatedApiKeyResponse{}, err
+	}
+	return response.CreatedApiKeyResponse{
+		ApiKeyResponse: created.ToApiKeyResponse(),
+		Key:            created.Prefix + "." + secret,
+	}, nil
+}
+
+func (s *apiKeyService) FindApiKeys(ctx context.Context, userId string) ([]response.ApiKeyResponse, error) {
+	res, err := s.apiKeyRepository.FindApiKeysByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []response.ApiKeyResponse{}
+	for _, v := range res {
+		data = append(data, v.ToApiKeyResponse())
+	}
+	return data, nil
+}
+
+func (s *apiKeyService) RevokeApiKey(ctx context.Context, userId string, id string) (response.ApiKeyResponse, error) {
+	key, err := s.apiKeyRepository.RevokeApiKey(ctx, id, userId)
+	if err != nil {
+		return response.ApiKeyResponse{}, err
+	}
+	return key.ToApiKeyResponse(), nil
+}
+
+// Authenticate checks an API key and returns its owner as the principal,
+// holding only the permissions of the owner that the key's scopes allow.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	prefix, secret, ok := strings.Cut(key, ".")
+	if !ok || !strings.HasPrefix(prefix, apiKeyPrefix) || secret == "" {
+		return domain.Principal{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken}
+	}
+
+	apiKey, err := s.apiKeyRepository.FindApiKeyByPrefix(ctx, prefix)
+	var notFoundErr *domain.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return domain.Principal{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: err}
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.SecretHash), []byte(hashApiKeySecret(secret))) != 1 {
+		return domain.Principal{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken}
+	}
+	if apiKey.RevokedAt != nil {
+		return domain.Principal{}, &domain.UnauthenticatedError{Reason: domain.ReasonTokenRevoked}
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return domain.Principal{}, &domain.UnauthenticatedError{Reason: domain.ReasonTokenExpired}
+	}
+
+	user, err := s.userRepository.FindByID(ctx, apiKey.UserId)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	permissions, err := s.roleRepository.FindUserPermissions(ctx, apiKey.UserId)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	// last_used_at is informational, a failed write must not fail the request.
+	if err := s.apiKeyRepository.TouchApiKey(ctx, apiKey.Id); err != nil {
+		log.Printf("Failed to record API key use: %v", err)
+	}
+
+	return domain.Principal{
+		UserId:      user.Id,
+		Email:       user.Email,
+		ApiKeyId:    apiKey.Id,
+		Permissions: domain.ScopePermissions(apiKey.Scopes, permissions),
+	}, nil
+}
+
+func hashApiKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// DefaultAuditPerPage is the page size of GET /audit without per_page.
+const DefaultAuditPerPage = 50
+
+// auditIgnoredFields are kept by the database on every write, or derived
+// from another record, so they are left out of the recorded changes.
+var auditIgnoredFields = map[string]bool{
+	"created_at":  true,
+	"updated_at":  true,
+	"created_by":  true,
+	"updated_by":  true,
+	"deleted_at":  true,
+	"author_name": true,
+}
+
+type AuditService interface {
+	FindAuditEntries(ctx context.Context, request request.AuditRequest, from time.Time, to time.Time) ([]response.AuditEntryResponse, int, error)
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) AuditService {
+	return &auditService{
+		auditRepository: auditRepository,
+	}
+}
+
+// FindAuditEntries returns one page of entries, newest first, and the number
+// of entries matching the filter.
+func (s *auditService) FindAuditEntries(ctx context.Context, request request.AuditRequest, from time.Time, to time.Time) ([]response.AuditEntryResponse, int, error) {
+	page, perPage := request.Page, request.PerPage
+	if page == 0 {
+		page = 1
+	}
+	if perPage == 0 {
+		perPage = DefaultAuditPerPage
+	}
+
+	res, total, err := s.auditRepository.FindAuditEntries(ctx, domain.AuditFilter{
+		Entity:   request.Entity,
+		EntityId: request.EntityId,
+		ActorId:  request.Actor,
+		From:     from,
+		To:       to,
+		Limit:    perPage,
+		Offset:   (page - 1) * perPage,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data := []response.AuditEntryResponse{}
+	for _, v := range res {
+		data = append(data, v.ToAuditEntryResponse())
+	}
+	return data, total, nil
+}
+
+// recordAudit writes an audit entry for a change of entity into the unit of
+// work of ctx and refuses to run without one. before is nil for creates and
+// after for deletes.
+func recordAudit(ctx context.Context, auditRepository repository.AuditRepository, action string, entity string, entityId string, before interface{}, after interface{}, details map[string]interface{}) error {
+	if !repository.InUnitOfWork(ctx) {
+		return repository.ErrNoUnitOfWork
+	}
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return err
+	}
+	// An update that changed nothing is not recorded.
+	if action == domain.AuditUpdate && len(changes) == 0 {
+		return nil
+	}
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+
+	entry := domain.AuditEntry{
+		Action:   action,
+		Entity:   entity,
+		EntityId: entityId,
+		Changes:  changes,
+		Details:  details,
+	}
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		entry.ActorId = &principal.UserId
+		if principal.ApiKeyId != "" {
+			entry.ApiKeyId = &principal.ApiKeyId
+		}
+	}
+	entry.RequestId, _ = ctx.Value(requestid.ConfigDefault.ContextKey).(string)
+
+	return auditRepository.CreateAuditEntry(ctx, entry)
+}
+
+// auditChanges compares the JSON representation of two versions of a record
+// and returns the fields that differ.
+func auditChanges(before interface{}, after interface{}) (map[string]domain.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]domain.AuditChange{}
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = domain.AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = domain.AuditChange{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func auditFields(record interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if record == nil {
+		return fields, nil
+	}
+
+	body, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for field := range fields {
+		if auditIgnoredFields[field] {
+			delete(fields, field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+)
+
+type AuthService interface {
+	Login(ctx context.Context, request request.LoginRequest) (response.TokenResponse, error)
+	Refresh(ctx context.Context, request request.RefreshRequest) (response.TokenResponse, error)
+	Logout(ctx context.Context, request request.RefreshRequest) error
+}
+
+type authService struct {
+	userRepository repository.UserRepository
+	tokenService   TokenService
+}
+
+func NewAuthService(userRepository repository.UserRepository, tokenService TokenService) AuthService {
+	return &authService{
+		userRepository: userRepository,
+		tokenService:   tokenService,
+	}
+}
+
+func (s *authService) Login(ctx context.Context, request request.LoginRequest) (response.TokenResponse, error) {
+	user, err := s.userRepository.FindByEmail(ctx, request.Email)
+	var notFoundErr *domain.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		checkPassword(string(dummyPasswordHash()), request.Password)
+		return response.TokenResponse{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidCredentials}
+	}
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	if !checkPassword(user.PasswordHash, request.Password) {
+		return response.TokenResponse{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidCredentials}
+	}
+	return s.tokenService.IssueTokens(ctx, user, "")
+}
+
+// Refresh trades a refresh token for a new pair. The old refresh token can
+// not be used again.
+func (s *authService) Refresh(ctx context.Context, request request.RefreshRequest) (response.TokenResponse, error) {
+	userId, family, err := s.tokenService.ConsumeRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userId)
+	var notFoundErr *domain.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return response.TokenResponse{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: err}
+	}
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	return s.tokenService.IssueTokens(ctx, user, family)
+}
+
+func (s *authService) Logout(ctx context.Context, request request.RefreshRequest) error {
+	return s.tokenService.RevokeRefreshToken(ctx, request.RefreshToken)
+}
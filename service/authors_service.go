@@ -2,32 +2,57 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
+	"test-backend-altech/config"
 	"test-backend-altech/exception"
+	"test-backend-altech/model/civil"
 	"test-backend-altech/model/domain"
 	request "test-backend-altech/model/web/req"
 	response "test-backend-altech/model/web/response"
 	"test-backend-altech/repository"
+
+	"github.com/go-playground/validator/v10"
 )
 
 type AuthorService interface {
 	CreateAuthor(ctx context.Context, request request.AuthorRequest) (response.AuthorResponse, error)
 	FindByID(ctx context.Context, id string) (response.AuthorResponse, error)
-	UpdateAuthor(ctx context.Context, request request.AuthorRequest, id string) (response.AuthorResponse, error)
-	FindAllAuthor(ctx context.Context) ([]response.AuthorResponse, error)
-	DeleteAuthor(ctx context.Context, id string) (response.AuthorResponse, error)
+	UpdateAuthor(ctx context.Context, request request.AuthorRequest, id string, version int) (response.AuthorResponse, error)
+	PatchAuthor(ctx context.Context, patch request.PatchRequest, id string, version int) (response.AuthorResponse, error)
+	FindAllAuthor(ctx context.Context, since time.Time) ([]response.AuthorResponse, error)
+	DeleteAuthor(ctx context.Context, id string, version int, policy request.DeleteAuthorRequest) (response.AuthorResponse, error)
+	RestoreAuthor(ctx context.Context, id string) (response.AuthorResponse, error)
+	MergeAuthors(ctx context.Context, id string, version int, merge request.MergeAuthorsRequest) (response.AuthorMergeResponse, error)
+	FindAuthorVersions(ctx context.Context, id string) ([]response.AuthorVersionResponse, error)
+	DiffAuthorVersions(ctx context.Context, id string, from int, to int) (response.VersionDiffResponse, error)
+	FindAuthorAsOf(ctx context.Context, id string, asOf time.Time) (response.AuthorResponse, error)
+	RevertAuthor(ctx context.Context, id string, toVersion int, version int) (response.AuthorResponse, error)
 }
 
 type authorService struct {
 	authorRepository repository.AuthorRepository
+	bookRepository   repository.BookRepository
+	auditRepository  repository.AuditRepository
+	outboxRepository repository.OutboxRepository
+	uow              repository.UnitOfWork
+	validate         *validator.Validate
+	cache            config.Cache
 }
 
-func NewAuthorService(authorRepository repository.AuthorRepository) AuthorService {
+func NewAuthorService(authorRepository repository.AuthorRepository, bookRepository repository.BookRepository, auditRepository repository.AuditRepository, outboxRepository repository.OutboxRepository, uow repository.UnitOfWork, validate *validator.Validate, cache config.Cache) AuthorService {
 	return &authorService{
 		authorRepository: authorRepository,
+		bookRepository:   bookRepository,
+		auditRepository:  auditRepository,
+		outboxRepository: outboxRepository,
+		uow:              uow,
+		validate:         validate,
+		cache:            cache,
 	}
 }
 
@@ -36,25 +61,30 @@ func (s *authorService) CreateAuthor(c context.Context, request request.AuthorRe
 		Name:      request.Name,
 		Bio:       request.Bio,
 		BirthDate: request.BirthDate,
-		CreatedAt: time.Now(),
 	}
 	author.GenerateID()
-
-	validateName, err := s.authorRepository.ValidateAuthorName(c, author.Name)
-	if err != nil {
+	if err := checkBirthDate(author.BirthDate); err != nil {
 		return response.AuthorResponse{}, err
 	}
-	if validateName.Name != "" {
-		return response.AuthorResponse{}, exception.ErrValidateBadRequest("Author name already exists", request)
-	}
 
-	if err := s.authorRepository.CreateAuthor(c, author); err != nil {
-		return response.AuthorResponse{}, err
-	}
+	var newAuthor domain.Author
+	err := s.uow.DoWithOptions(c, repository.SerializableTx, func(c context.Context) error {
+		if err := s.authorRepository.CreateAuthor(c, author); err != nil {
+			return err
+		}
 
-	newAuthor, err := s.authorRepository.FindByID(c, author.Id)
+		var err error
+		newAuthor, err = s.authorRepository.FindByID(c, author.Id)
+		if err != nil {
+			return exception.ErrInternalServer(fmt.Sprintf("Successfully created author, but failed to get the created author. Error: %s", err.Error()))
+		}
+		if err := recordAudit(c, s.auditRepository, domain.AuditCreate, domain.AuditEntityAuthor, author.Id, nil, newAuthor.ToAuthorResponse(), nil); err != nil {
+			return err
+		}
+		return recordEvent(c, s.outboxRepository, domain.EventAuthorCreated, domain.AuditEntityAuthor, author.Id, newAuthor.ToAuthorResponse())
+	})
 	if err != nil {
-		return response.AuthorResponse{}, exception.ErrInternalServer(fmt.Sprintf("Successfully created author, but failed to get the created author. Error: %s", err.Error()))
+		return response.AuthorResponse{}, err
 	}
 
 	return newAuthor.ToAuthorResponse(), err
@@ -62,49 +92,138 @@ func (s *authorService) CreateAuthor(c context.Context, request request.AuthorRe
 func (s *authorService) FindByID(ctx context.Context, id string) (response.AuthorResponse, error) {
 	res, err := s.authorRepository.FindByID(ctx, id)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.AuthorResponse{}, exception.ErrNotFound("Author not found")
-		} else {
-			return response.AuthorResponse{}, err
-		}
+		return response.AuthorResponse{}, err
 	}
 
 	return res.ToAuthorResponse(), err
 }
 
-func (s *authorService) UpdateAuthor(ctx context.Context, request request.AuthorRequest, id string) (response.AuthorResponse, error) {
-	data, err := s.authorRepository.FindByID(ctx, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.AuthorResponse{}, exception.ErrNotFound("Author not found")
-		} else {
-			return response.AuthorResponse{}, err
+func (s *authorService) UpdateAuthor(ctx context.Context, request request.AuthorRequest, id string, version int) (response.AuthorResponse, error) {
+	var data domain.Author
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwnership(ctx, data.CreatedBy, domain.PermissionAuthorsUpdateAny); err != nil {
+			return err
 		}
-	}
 
-	author := domain.UpdateAuthor{
-		Name:      request.Name,
-		Bio:       request.Bio,
-		BirthDate: request.BirthDate,
+		author := domain.UpdateAuthor{
+			Name:      request.Name,
+			Bio:       request.Bio,
+			BirthDate: request.BirthDate,
+			Version:   version,
+		}
+		if err := checkBirthDate(author.BirthDate); err != nil {
+			return err
+		}
+		earliestPublishDate, err := s.authorRepository.EarliestPublishDate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkPublishDate(earliestPublishDate, author.BirthDate); err != nil {
+			return err
+		}
+		if err := s.authorRepository.UpdateAuthor(ctx, id, author); err != nil {
+			return err
+		}
+
+		before := data.ToAuthorResponse()
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditUpdate, domain.AuditEntityAuthor, id, before, data.ToAuthorResponse(), nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventAuthorUpdated, domain.AuditEntityAuthor, id, data.ToAuthorResponse())
+	})
+	if err != nil {
+		return response.AuthorResponse{}, err
 	}
-	data.Bio = author.Bio
-	data.Name = author.Name
-	data.BirthDate = author.BirthDate
+	// Books embed the author name, so the cached list goes stale too.
+	invalidateBooksCache(ctx, s.cache)
+	return data.ToAuthorResponse(), err
+}
+
+func (s *authorService) PatchAuthor(ctx context.Context, patch request.PatchRequest, id string, version int) (response.AuthorResponse, error) {
+	var data domain.Author
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwnership(ctx, current.CreatedBy, domain.PermissionAuthorsUpdateAny); err != nil {
+			return err
+		}
+
+		original := request.AuthorRequest{
+			Name:      current.Name,
+			Bio:       current.Bio,
+			BirthDate: current.BirthDate,
+		}
+		var patched request.AuthorRequest
+		if err := applyPatch(original, patch, &patched); err != nil {
+			return err
+		}
+		if err := s.validate.Struct(patched); err != nil {
+			return exception.ErrValidateBadRequest(err)
+		}
+
+		changes := domain.Changes{}
+		if patched.Name != original.Name {
+			changes["name"] = patched.Name
+		}
+		if patched.Bio != original.Bio {
+			changes["bio"] = patched.Bio
+		}
+		if patched.BirthDate != original.BirthDate {
+			if err := checkBirthDate(patched.BirthDate); err != nil {
+				return err
+			}
+			earliestPublishDate, err := s.authorRepository.EarliestPublishDate(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := checkPublishDate(earliestPublishDate, patched.BirthDate); err != nil {
+				return err
+			}
+			changes["birth_date"] = patched.BirthDate
+		}
 
-	if err := s.authorRepository.UpdateAuthor(ctx, id, author); err != nil {
+		if len(changes) > 0 {
+			if err := s.authorRepository.PatchAuthor(ctx, id, version, changes); err != nil {
+				return err
+			}
+		} else if version != 0 && version != current.Version {
+			return &domain.PreconditionFailedError{Entity: domain.EntityAuthor}
+		}
+
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditUpdate, domain.AuditEntityAuthor, id, current.ToAuthorResponse(), data.ToAuthorResponse(), nil); err != nil {
+			return err
+		}
+		if data.Version == current.Version {
+			return nil
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventAuthorUpdated, domain.AuditEntityAuthor, id, data.ToAuthorResponse())
+	})
+	if err != nil {
 		return response.AuthorResponse{}, err
 	}
+	invalidateBooksCache(ctx, s.cache)
 	return data.ToAuthorResponse(), err
 }
 
-func (s *authorService) FindAllAuthor(ctx context.Context) ([]response.AuthorResponse, error) {
-	res, err := s.authorRepository.FindAllAuthor(ctx)
+func (s *authorService) FindAllAuthor(ctx context.Context, since time.Time) ([]response.AuthorResponse, error) {
+	res, err := s.authorRepository.FindAllAuthor(ctx, since)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return []response.AuthorResponse{}, exception.ErrNotFound("Author not found")
-		} else {
-			return []response.AuthorResponse{}, err
-		}
+		return []response.AuthorResponse{}, err
 	}
 
 	var data []response.AuthorResponse
@@ -114,18 +233,370 @@ func (s *authorService) FindAllAuthor(ctx context.Context) ([]response.AuthorRes
 	return data, err
 }
 
-func (s *authorService) DeleteAuthor(ctx context.Context, id string) (response.AuthorResponse, error) {
-	data, err := s.authorRepository.FindByID(ctx, id)
+// DeleteAuthor moves the author to the trash. What happens to the books of
+// the author is chosen by policy.OnBooks: restrict refuses while the author
+// has books, cascade trashes them too and reassign moves them to policy.To.
+// It runs serializable so no book can be added to the author meanwhile.
+func (s *authorService) DeleteAuthor(ctx context.Context, id string, version int, policy request.DeleteAuthorRequest) (response.AuthorResponse, error) {
+	var data domain.Author
+	err := s.uow.DoWithOptions(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		var err error
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		details := map[string]interface{}{}
+		switch policy.OnBooks {
+		case request.OnBooksCascade:
+			deleted, err := s.trashBooks(ctx, id)
+			if err != nil {
+				return err
+			}
+			details["on_books"] = policy.OnBooks
+			details["books_deleted"] = deleted
+		case request.OnBooksReassign:
+			moved, err := s.reassignBooks(ctx, id, policy.To)
+			if err != nil {
+				return err
+			}
+			details["on_books"] = policy.OnBooks
+			details["books_moved"] = moved
+			details["to"] = policy.To
+		default:
+			references, err := s.bookRepository.FindReferencesByAuthor(ctx, id)
+			if err != nil {
+				return err
+			}
+			if len(references) > 0 {
+				return &domain.DependentsError{Entity: domain.EntityAuthor, Dependent: domain.EntityBook, References: references}
+			}
+		}
+
+		if err := s.authorRepository.DeleteAuthor(ctx, id, version); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditDelete, domain.AuditEntityAuthor, id, data.ToAuthorResponse(), nil, details); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventAuthorDeleted, domain.AuditEntityAuthor, id, data.ToAuthorResponse())
+	})
+	if err != nil {
+		return response.AuthorResponse{}, err
+	}
+	invalidateBooksCache(ctx, s.cache)
+	return data.ToAuthorResponse(), err
+}
+
+// reassignBooks moves the books of one author to another, which must exist
+// and be born before the earliest of those books was published. It returns
+// the number of books moved.
+func (s *authorService) reassignBooks(ctx context.Context, fromAuthorId string, toAuthorId string) (int64, error) {
+	if toAuthorId == fromAuthorId {
+		return 0, &domain.InvalidInputError{Field: "to", Reason: "must be another author"}
+	}
+
+	target, err := s.authorRepository.FindByID(ctx, toAuthorId)
+	var notFoundErr *domain.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return 0, &domain.InvalidReferenceError{Entity: domain.EntityAuthor, Field: "to", Err: err}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	earliestPublishDate, err := s.authorRepository.EarliestPublishDate(ctx, fromAuthorId)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkPublishDate(earliestPublishDate, target.BirthDate); err != nil {
+		return 0, err
+	}
+
+	return s.moveBooks(ctx, fromAuthorId, toAuthorId)
+}
+
+// moveBooks moves the active books of one author to another and records an
+// audit entry and a BookUpdated event for each of them.
+func (s *authorService) moveBooks(ctx context.Context, fromAuthorId string, toAuthorId string) (int64, error) {
+	before, err := s.activeBooks(ctx, fromAuthorId)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := s.bookRepository.ReassignBooks(ctx, fromAuthorId, toAuthorId)
+	if err != nil {
+		return 0, err
+	}
+
+	details := map[string]interface{}{"moved_from": fromAuthorId}
+	for _, previous := range before {
+		book, err := s.bookRepository.FindByID(ctx, previous.Id)
+		if err != nil {
+			return 0, err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditUpdate, domain.AuditEntityBook, book.Id, previous, book, details); err != nil {
+			return 0, err
+		}
+		if err := recordEvent(ctx, s.outboxRepository, domain.EventBookUpdated, domain.AuditEntityBook, book.Id, book); err != nil {
+			return 0, err
+		}
+	}
+	return moved, nil
+}
+
+// trashBooks moves the active books of the author to the trash and records an
+// audit entry and a BookDeleted event for each of them.
+func (s *authorService) trashBooks(ctx context.Context, authorId string) (int64, error) {
+	books, err := s.activeBooks(ctx, authorId)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := s.bookRepository.DeleteBooksByAuthor(ctx, authorId)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.AuthorResponse{}, exception.ErrNotFound("Author not found")
-		} else {
-			return response.AuthorResponse{}, err
+		return 0, err
+	}
+
+	details := map[string]interface{}{"deleted_with": authorId}
+	for _, book := range books {
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditDelete, domain.AuditEntityBook, book.Id, book, nil, details); err != nil {
+			return 0, err
+		}
+		if err := recordEvent(ctx, s.outboxRepository, domain.EventBookDeleted, domain.AuditEntityBook, book.Id, book); err != nil {
+			return 0, err
 		}
 	}
+	return deleted, nil
+}
 
-	if err := s.authorRepository.DeleteAuthor(ctx, id); err != nil {
+// activeBooks returns the books of the author that are not in the trash.
+func (s *authorService) activeBooks(ctx context.Context, authorId string) ([]response.BookResponse, error) {
+	references, err := s.bookRepository.FindReferencesByAuthor(ctx, authorId)
+	if err != nil {
+		return nil, err
+	}
+	books := make([]response.BookResponse, 0, len(references))
+	for _, reference := range references {
+		book, err := s.bookRepository.FindByID(ctx, reference.Id)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+func (s *authorService) RestoreAuthor(ctx context.Context, id string) (response.AuthorResponse, error) {
+	var data domain.Author
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.authorRepository.RestoreAuthor(ctx, id); err != nil {
+			return err
+		}
+
+		var err error
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditRestore, domain.AuditEntityAuthor, id, nil, data.ToAuthorResponse(), nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventAuthorRestored, domain.AuditEntityAuthor, id, data.ToAuthorResponse())
+	})
+	if err != nil {
 		return response.AuthorResponse{}, err
 	}
+	invalidateBooksCache(ctx, s.cache)
 	return data.ToAuthorResponse(), err
 }
+
+// MergeAuthors folds duplicate authors into the author id: their books move
+// to it, bio and birth_date are combined by merge.Precedence and the
+// duplicates go to the trash. The merge is recorded in author_merges.
+func (s *authorService) MergeAuthors(ctx context.Context, id string, version int, merge request.MergeAuthorsRequest) (response.AuthorMergeResponse, error) {
+	record := domain.AuthorMerge{
+		SurvivorId: id,
+		Precedence: merge.Precedence,
+	}
+	if record.Precedence == "" {
+		record.Precedence = request.PrecedenceSurvivor
+	}
+	for _, duplicateId := range merge.Duplicates {
+		if duplicateId == id {
+			return response.AuthorMergeResponse{}, &domain.InvalidInputError{Field: "duplicates", Reason: "must not contain the surviving author"}
+		}
+		if !slices.Contains(record.DuplicateIds, duplicateId) {
+			record.DuplicateIds = append(record.DuplicateIds, duplicateId)
+		}
+	}
+	record.GenerateID()
+
+	var data domain.Author
+	err := s.uow.DoWithOptions(ctx, repository.SerializableTx, func(ctx context.Context) error {
+		survivor, err := s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		duplicates := make([]domain.Author, 0, len(record.DuplicateIds))
+		for _, duplicateId := range record.DuplicateIds {
+			duplicate, err := s.authorRepository.FindByID(ctx, duplicateId)
+			var notFoundErr *domain.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				return &domain.InvalidReferenceError{Entity: domain.EntityAuthor, Field: "duplicates", Err: err}
+			}
+			if err != nil {
+				return err
+			}
+			duplicates = append(duplicates, duplicate)
+		}
+
+		for _, duplicate := range duplicates {
+			moved, err := s.moveBooks(ctx, duplicate.Id, id)
+			if err != nil {
+				return err
+			}
+			record.BooksMoved += moved
+
+			if err := s.authorRepository.DeleteAuthor(ctx, duplicate.Id, 0); err != nil {
+				return err
+			}
+			details := map[string]interface{}{"merged_into": id, "books_moved": moved}
+			if err := recordAudit(ctx, s.auditRepository, domain.AuditDelete, domain.AuditEntityAuthor, duplicate.Id, duplicate.ToAuthorResponse(), nil, details); err != nil {
+				return err
+			}
+			if err := recordEvent(ctx, s.outboxRepository, domain.EventAuthorDeleted, domain.AuditEntityAuthor, duplicate.Id, duplicate.ToAuthorResponse()); err != nil {
+				return err
+			}
+		}
+
+		bio, birthDate := mergeAuthorFields(survivor, duplicates, record.Precedence)
+		earliestPublishDate, err := s.authorRepository.EarliestPublishDate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkPublishDate(earliestPublishDate, birthDate); err != nil {
+			return err
+		}
+
+		changes := domain.Changes{}
+		if bio != survivor.Bio {
+			changes["bio"] = bio
+		}
+		if birthDate != survivor.BirthDate {
+			changes["birth_date"] = birthDate
+		}
+		if len(changes) > 0 {
+			if err := s.authorRepository.PatchAuthor(ctx, id, version, changes); err != nil {
+				return err
+			}
+		} else if version != 0 && version != survivor.Version {
+			return &domain.PreconditionFailedError{Entity: domain.EntityAuthor}
+		}
+
+		record.MergedAt, err = s.authorRepository.CreateAuthorMerge(ctx, record)
+		if err != nil {
+			return err
+		}
+
+		data, err = s.authorRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		details := map[string]interface{}{
+			"merge_id":    record.Id,
+			"duplicates":  record.DuplicateIds,
+			"precedence":  record.Precedence,
+			"books_moved": record.BooksMoved,
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditMerge, domain.AuditEntityAuthor, id, survivor.ToAuthorResponse(), data.ToAuthorResponse(), details); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventAuthorsMerged, domain.AuditEntityAuthor, id, record.ToAuthorMergeResponse(data))
+	})
+	if err != nil {
+		return response.AuthorMergeResponse{}, err
+	}
+	invalidateBooksCache(ctx, s.cache)
+
+	return record.ToAuthorMergeResponse(data), nil
+}
+
+// mergeAuthorFields picks the bio and birth_date of a merged author. The side
+// named by precedence is tried first and empty values fall through to the
+// next author.
+func mergeAuthorFields(survivor domain.Author, duplicates []domain.Author, precedence string) (string, civil.Date) {
+	candidates := slices.Concat([]domain.Author{survivor}, duplicates)
+	if precedence == request.PrecedenceDuplicate {
+		candidates = slices.Concat(duplicates, []domain.Author{survivor})
+	}
+
+	var bio string
+	var birthDate civil.Date
+	for _, candidate := range candidates {
+		if strings.TrimSpace(bio) == "" {
+			bio = candidate.Bio
+		}
+		if birthDate.IsZero() {
+			birthDate = candidate.BirthDate
+		}
+	}
+	return bio, birthDate
+}
+
+// FindAuthorVersions returns every recorded version of the author, oldest
+// first.
+func (s *authorService) FindAuthorVersions(ctx context.Context, id string) ([]response.AuthorVersionResponse, error) {
+	res, err := s.authorRepository.FindAuthorVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, &domain.NotFoundError{Entity: domain.EntityAuthor}
+	}
+
+	data := []response.AuthorVersionResponse{}
+	for _, v := range res {
+		data = append(data, v.ToAuthorVersionResponse())
+	}
+	return data, nil
+}
+
+func (s *authorService) DiffAuthorVersions(ctx context.Context, id string, from int, to int) (response.VersionDiffResponse, error) {
+	before, err := s.authorRepository.FindAuthorVersion(ctx, id, from)
+	if err != nil {
+		return response.VersionDiffResponse{}, err
+	}
+	after, err := s.authorRepository.FindAuthorVersion(ctx, id, to)
+	if err != nil {
+		return response.VersionDiffResponse{}, err
+	}
+	return versionDiff(from, to, before.Author.ToAuthorResponse(), after.Author.ToAuthorResponse())
+}
+
+func (s *authorService) FindAuthorAsOf(ctx context.Context, id string, asOf time.Time) (response.AuthorResponse, error) {
+	res, err := s.authorRepository.FindAuthorAsOf(ctx, id, asOf)
+	if err != nil {
+		return response.AuthorResponse{}, err
+	}
+	return res.ToAuthorResponse(), nil
+}
+
+// RevertAuthor writes the fields of a recorded version back through
+// UpdateAuthor, so the revert is validated, checked and audited like any
+// other update and becomes a new version itself.
+func (s *authorService) RevertAuthor(ctx context.Context, id string, toVersion int, version int) (response.AuthorResponse, error) {
+	target, err := s.authorRepository.FindAuthorVersion(ctx, id, toVersion)
+	if err != nil {
+		return response.AuthorResponse{}, err
+	}
+
+	request := request.AuthorRequest{
+		Name:      target.Author.Name,
+		Bio:       target.Author.Bio,
+		BirthDate: target.Author.BirthDate,
+	}
+	if err := s.validate.Struct(request); err != nil {
+		return response.AuthorResponse{}, exception.ErrValidateBadRequest(err)
+	}
+	return s.UpdateAuthor(ctx, request, id, version)
+}
@@ -3,9 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"test-backend-altech/config"
@@ -14,25 +14,43 @@ import (
 	request "test-backend-altech/model/web/req"
 	response "test-backend-altech/model/web/response"
 	"test-backend-altech/repository"
+
+	"github.com/go-playground/validator/v10"
 )
 
 type BookService interface {
 	CreateBook(ctx context.Context, request request.BookRequest) (response.BookResponse, error)
 	FindByID(ctx context.Context, id string) (response.BookResponse, error)
-	UpdateBook(ctx context.Context, request request.BookRequest, id string) (response.BookResponse, error)
-	FindAllBook(ctx context.Context, cache config.Cache) ([]response.BookResponse, error)
-	DeleteBook(ctx context.Context, id string) (response.BookResponse, error)
+	UpdateBook(ctx context.Context, request request.BookRequest, id string, version int) (response.BookResponse, error)
+	PatchBook(ctx context.Context, patch request.PatchRequest, id string, version int) (response.BookResponse, error)
+	FindAllBook(ctx context.Context, cache config.Cache, since time.Time) ([]response.BookResponse, error)
+	DeleteBook(ctx context.Context, id string, version int) (response.BookResponse, error)
+	RestoreBook(ctx context.Context, id string) (response.BookResponse, error)
+	FindBookVersions(ctx context.Context, id string) ([]response.BookVersionResponse, error)
+	DiffBookVersions(ctx context.Context, id string, from int, to int) (response.VersionDiffResponse, error)
+	FindBookAsOf(ctx context.Context, id string, asOf time.Time) (response.BookResponse, error)
+	RevertBook(ctx context.Context, id string, toVersion int, version int) (response.BookResponse, error)
 }
 
 type bookService struct {
-	bookRepository repository.BookRepository
-	cache          config.Cache
+	bookRepository   repository.BookRepository
+	authorRepository repository.AuthorRepository
+	auditRepository  repository.AuditRepository
+	outboxRepository repository.OutboxRepository
+	uow              repository.UnitOfWork
+	validate         *validator.Validate
+	cache            config.Cache
 }
 
-func NewBookService(bookRepository repository.BookRepository, cache config.Cache) BookService {
+func NewBookService(bookRepository repository.BookRepository, authorRepository repository.AuthorRepository, auditRepository repository.AuditRepository, outboxRepository repository.OutboxRepository, uow repository.UnitOfWork, validate *validator.Validate, cache config.Cache) BookService {
 	return &bookService{
-		bookRepository: bookRepository,
-		cache:          cache,
+		bookRepository:   bookRepository,
+		authorRepository: authorRepository,
+		auditRepository:  auditRepository,
+		outboxRepository: outboxRepository,
+		uow:              uow,
+		validate:         validate,
+		cache:            cache,
 	}
 }
 
@@ -42,75 +60,229 @@ func (s *bookService) CreateBook(c context.Context, request request.BookRequest)
 		Description: request.Description,
 		PublishDate: request.PublishDate,
 		AuthorId:    request.AuthorId,
-		CreatedAt:   time.Now(),
 	}
 	book.GenerateID()
 
-	validateTitle, err := s.bookRepository.ValidateBookTitle(c, book.Title)
+	var newBook response.BookResponse
+	err := s.uow.DoWithOptions(c, repository.SerializableTx, func(c context.Context) error {
+		author, err := s.resolveAuthor(c, request)
+		if err != nil {
+			return err
+		}
+		if err := checkPublishDate(book.PublishDate, author.BirthDate); err != nil {
+			return err
+		}
+		book.AuthorId = author.Id
+
+		if err := s.bookRepository.CreateBook(c, book); err != nil {
+			return err
+		}
+
+		newBook, err = s.bookRepository.FindByID(c, book.Id)
+		if err != nil {
+			return exception.ErrInternalServer(fmt.Sprintf("Successfully created book, but failed to get the created book. Error: %s", err.Error()))
+		}
+		if err := recordAudit(c, s.auditRepository, domain.AuditCreate, domain.AuditEntityBook, book.Id, nil, newBook, nil); err != nil {
+			return err
+		}
+		return recordEvent(c, s.outboxRepository, domain.EventBookCreated, domain.AuditEntityBook, book.Id, newBook)
+	})
 	if err != nil {
 		return response.BookResponse{}, err
 	}
-	if validateTitle.Title != "" {
-		return response.BookResponse{}, exception.ErrValidateBadRequest("Book name already exists", request)
+	invalidateBooksCache(c, s.cache)
+
+	return newBook, err
+}
+
+// resolveAuthor returns the author of the request, creating the nested
+// author first when one is given. It must run inside a unit of work so the
+// author is rolled back together with the book. Creating the nested author
+// needs authors.create on top of the permission of the book route.
+func (s *bookService) resolveAuthor(c context.Context, request request.BookRequest) (domain.Author, error) {
+	if request.Author == nil {
+		author, err := s.authorRepository.FindByID(c, request.AuthorId)
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return domain.Author{}, &domain.InvalidReferenceError{Entity: domain.EntityBook, Field: "author_id", Err: err}
+		}
+		return author, err
+	}
+	if err := checkPermission(c, domain.PermissionAuthorsCreate); err != nil {
+		return domain.Author{}, err
 	}
 
-	if err := s.bookRepository.CreateBook(c, book); err != nil {
-		return response.BookResponse{}, err
+	author := domain.Author{
+		Name:      request.Author.Name,
+		Bio:       request.Author.Bio,
+		BirthDate: request.Author.BirthDate,
+	}
+	author.GenerateID()
+	if err := checkBirthDate(author.BirthDate); err != nil {
+		return domain.Author{}, err
 	}
 
-	newBook, err := s.bookRepository.FindByID(c, book.Id)
+	if err := s.authorRepository.CreateAuthor(c, author); err != nil {
+		return domain.Author{}, err
+	}
+	created, err := s.authorRepository.FindByID(c, author.Id)
 	if err != nil {
-		return response.BookResponse{}, exception.ErrInternalServer(fmt.Sprintf("Successfully created book, but failed to get the created book. Error: %s", err.Error()))
+		return domain.Author{}, err
 	}
-
-	return newBook, err
+	details := map[string]interface{}{"nested_in": domain.AuditEntityBook}
+	if err := recordAudit(c, s.auditRepository, domain.AuditCreate, domain.AuditEntityAuthor, author.Id, nil, created.ToAuthorResponse(), details); err != nil {
+		return domain.Author{}, err
+	}
+	if err := recordEvent(c, s.outboxRepository, domain.EventAuthorCreated, domain.AuditEntityAuthor, author.Id, created.ToAuthorResponse()); err != nil {
+		return domain.Author{}, err
+	}
+	return created, nil
 }
 func (s *bookService) FindByID(ctx context.Context, id string) (response.BookResponse, error) {
 	res, err := s.bookRepository.FindByID(ctx, id)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.BookResponse{}, exception.ErrNotFound("Book not found")
-		} else {
-			return response.BookResponse{}, err
-		}
+		return response.BookResponse{}, err
 	}
 
 	return res, err
 }
 
-func (s *bookService) UpdateBook(ctx context.Context, request request.BookRequest, id string) (response.BookResponse, error) {
-	data, err := s.bookRepository.FindByID(ctx, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.BookResponse{}, exception.ErrNotFound("Book not found")
-		} else {
-			return response.BookResponse{}, err
+func (s *bookService) UpdateBook(ctx context.Context, request request.BookRequest, id string, version int) (response.BookResponse, error) {
+	var data response.BookResponse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, err = s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwnership(ctx, data.CreatedBy, domain.PermissionBooksUpdateAny); err != nil {
+			return err
 		}
-	}
 
-	book := domain.UpdateBook{
-		Title:       request.Title,
-		Description: request.Description,
-		PublishDate: request.PublishDate,
-		AuthorId:    request.AuthorId,
+		author, err := s.resolveAuthor(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		book := domain.UpdateBook{
+			Title:       request.Title,
+			Description: request.Description,
+			PublishDate: request.PublishDate,
+			AuthorId:    author.Id,
+			Version:     version,
+		}
+		if err := checkPublishDate(book.PublishDate, author.BirthDate); err != nil {
+			return err
+		}
+		if err := s.bookRepository.UpdateBook(ctx, id, book); err != nil {
+			return err
+		}
+
+		before := data
+		data, err = s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditUpdate, domain.AuditEntityBook, id, before, data, nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventBookUpdated, domain.AuditEntityBook, id, data)
+	})
+	if err != nil {
+		return response.BookResponse{}, err
 	}
-	data.Description = book.Description
-	data.Title = book.Title
-	data.PublishDate = book.PublishDate
-	data.AuthorId = book.AuthorId
+	invalidateBooksCache(ctx, s.cache)
+	return data, err
+}
+
+func (s *bookService) PatchBook(ctx context.Context, patch request.PatchRequest, id string, version int) (response.BookResponse, error) {
+	var data response.BookResponse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkOwnership(ctx, current.CreatedBy, domain.PermissionBooksUpdateAny); err != nil {
+			return err
+		}
+
+		original := request.BookRequest{
+			Title:       current.Title,
+			Description: current.Description,
+			AuthorId:    current.AuthorId,
+			PublishDate: current.PublishDate,
+		}
+		var patched request.BookRequest
+		if err := applyPatch(original, patch, &patched); err != nil {
+			return err
+		}
+		// A patch moves a book to another author only by author_id, nested
+		// authors are created by POST and PUT.
+		if patched.Author != nil {
+			return &domain.InvalidInputError{Field: "author", Reason: "can not be patched, use author_id"}
+		}
+		if err := s.validate.Struct(patched); err != nil {
+			return exception.ErrValidateBadRequest(err)
+		}
+
+		changes := domain.Changes{}
+		if patched.Title != original.Title {
+			changes["title"] = patched.Title
+		}
+		if patched.Description != original.Description {
+			changes["description"] = patched.Description
+		}
+		if patched.AuthorId != original.AuthorId || patched.PublishDate != original.PublishDate {
+			author, err := s.resolveAuthor(ctx, patched)
+			if err != nil {
+				return err
+			}
+			if err := checkPublishDate(patched.PublishDate, author.BirthDate); err != nil {
+				return err
+			}
+			if author.Id != original.AuthorId {
+				changes["author_id"] = author.Id
+			}
+			if patched.PublishDate != original.PublishDate {
+				changes["publish_date"] = patched.PublishDate
+			}
+		}
 
-	if err := s.bookRepository.UpdateBook(ctx, id, book); err != nil {
+		if len(changes) > 0 {
+			if err := s.bookRepository.PatchBook(ctx, id, version, changes); err != nil {
+				return err
+			}
+		} else if version != 0 && version != current.Version {
+			return &domain.PreconditionFailedError{Entity: domain.EntityBook}
+		}
+
+		data, err = s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditUpdate, domain.AuditEntityBook, id, current, data, nil); err != nil {
+			return err
+		}
+		if data.Version == current.Version {
+			return nil
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventBookUpdated, domain.AuditEntityBook, id, data)
+	})
+	if err != nil {
 		return response.BookResponse{}, err
 	}
+	invalidateBooksCache(ctx, s.cache)
 	return data, err
 }
 
-func (s *bookService) FindAllBook(ctx context.Context, cache config.Cache) ([]response.BookResponse, error) {
-	bookKey := "books"
-	cacheKey := fmt.Sprintf("driver:%s", bookKey)
+func (s *bookService) FindAllBook(ctx context.Context, cache config.Cache, since time.Time) ([]response.BookResponse, error) {
+	// Only the full list is cached, incremental reads go to the database.
+	if !since.IsZero() {
+		return s.bookRepository.FindAllBook(ctx, since)
+	}
 
 	var dataRedis []response.BookResponse
-	cachedData, err := s.cache.Get(ctx, cacheKey)
+	cachedData, err := s.cache.Get(ctx, booksCacheKey)
 	if err == nil {
 		if err := json.Unmarshal(cachedData, &dataRedis); err == nil {
 			log.Println("Cache hit")
@@ -120,13 +292,9 @@ func (s *bookService) FindAllBook(ctx context.Context, cache config.Cache) ([]re
 		}
 	}
 
-	res, err := s.bookRepository.FindAllBook(ctx)
+	res, err := s.bookRepository.FindAllBook(ctx, since)
 	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return []response.BookResponse{}, exception.ErrNotFound("Book not found")
-		} else {
-			return []response.BookResponse{}, err
-		}
+		return []response.BookResponse{}, err
 	}
 
 	var data []response.BookResponse
@@ -138,24 +306,122 @@ func (s *bookService) FindAllBook(ctx context.Context, cache config.Cache) ([]re
 		return nil, err
 	}
 
-	if err := s.cache.Set(ctx, cacheKey, dbResponseBytes, time.Hour*168); err != nil {
+	if err := s.cache.Set(ctx, booksCacheKey, dbResponseBytes, booksCacheTTL); err != nil {
 		log.Printf("Failed to cache data: %v", err)
 	}
 	return data, err
 }
 
-func (s *bookService) DeleteBook(ctx context.Context, id string) (response.BookResponse, error) {
-	data, err := s.bookRepository.FindByID(ctx, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "no rows") {
-			return response.BookResponse{}, exception.ErrNotFound("Book not found")
-		} else {
-			return response.BookResponse{}, err
+func (s *bookService) DeleteBook(ctx context.Context, id string, version int) (response.BookResponse, error) {
+	var data response.BookResponse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data, err = s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
 		}
+
+		if err := s.bookRepository.DeleteBook(ctx, id, version); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditDelete, domain.AuditEntityBook, id, data, nil, nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventBookDeleted, domain.AuditEntityBook, id, data)
+	})
+	if err != nil {
+		return response.BookResponse{}, err
 	}
+	invalidateBooksCache(ctx, s.cache)
+	return data, err
+}
 
-	if err := s.bookRepository.DeleteBook(ctx, id); err != nil {
+// RestoreBook takes a book out of the trash. The author must not be in the
+// trash itself, restore the author first in that case.
+func (s *bookService) RestoreBook(ctx context.Context, id string) (response.BookResponse, error) {
+	var data response.BookResponse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.bookRepository.RestoreBook(ctx, id); err != nil {
+			return err
+		}
+
+		var err error
+		data, err = s.bookRepository.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.authorRepository.FindByID(ctx, data.AuthorId)
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return &domain.InvalidReferenceError{Entity: domain.EntityBook, Field: "author_id", Err: err}
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, s.auditRepository, domain.AuditRestore, domain.AuditEntityBook, id, nil, data, nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, s.outboxRepository, domain.EventBookRestored, domain.AuditEntityBook, id, data)
+	})
+	if err != nil {
 		return response.BookResponse{}, err
 	}
+	invalidateBooksCache(ctx, s.cache)
 	return data, err
 }
+
+// FindBookVersions returns every recorded version of the book, oldest first.
+func (s *bookService) FindBookVersions(ctx context.Context, id string) ([]response.BookVersionResponse, error) {
+	res, err := s.bookRepository.FindBookVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, &domain.NotFoundError{Entity: domain.EntityBook}
+	}
+
+	data := []response.BookVersionResponse{}
+	for _, v := range res {
+		data = append(data, v.ToBookVersionResponse())
+	}
+	return data, nil
+}
+
+func (s *bookService) DiffBookVersions(ctx context.Context, id string, from int, to int) (response.VersionDiffResponse, error) {
+	before, err := s.bookRepository.FindBookVersion(ctx, id, from)
+	if err != nil {
+		return response.VersionDiffResponse{}, err
+	}
+	after, err := s.bookRepository.FindBookVersion(ctx, id, to)
+	if err != nil {
+		return response.VersionDiffResponse{}, err
+	}
+	return versionDiff(from, to, before.Book, after.Book)
+}
+
+func (s *bookService) FindBookAsOf(ctx context.Context, id string, asOf time.Time) (response.BookResponse, error) {
+	return s.bookRepository.FindBookAsOf(ctx, id, asOf)
+}
+
+// RevertBook writes the fields of a recorded version back through
+// UpdateBook, so the revert is validated, checked and audited like any other
+// update and becomes a new version itself. The author of that version must
+// still be active.
+func (s *bookService) RevertBook(ctx context.Context, id string, toVersion int, version int) (response.BookResponse, error) {
+	target, err := s.bookRepository.FindBookVersion(ctx, id, toVersion)
+	if err != nil {
+		return response.BookResponse{}, err
+	}
+
+	request := request.BookRequest{
+		Title:       target.Book.Title,
+		Description: target.Book.Description,
+		AuthorId:    target.Book.AuthorId,
+		PublishDate: target.Book.PublishDate,
+	}
+	if err := s.validate.Struct(request); err != nil {
+		return response.BookResponse{}, exception.ErrValidateBadRequest(err)
+	}
+	return s.UpdateBook(ctx, request, id, version)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"test-backend-altech/config"
+)
+
+// booksCacheKey holds the cached result of FindAllBook.
+const booksCacheKey = "driver:books"
+
+// booksCacheTTL bounds how long the cached book list can be stale when an
+// invalidation is lost, or a read that started before a write stores the
+// list after the write invalidated it.
+const booksCacheTTL = 5 * time.Minute
+
+// invalidateBooksCache drops the cached book list after a write that changes
+// it. The write is already committed, so a failure is tried once more and
+// then only logged; the list is stale for at most booksCacheTTL.
+func invalidateBooksCache(ctx context.Context, cache config.Cache) {
+	err := cache.Delete(ctx, booksCacheKey)
+	if err != nil {
+		err = cache.Delete(ctx, booksCacheKey)
+	}
+	if err != nil {
+		log.Printf("Failed to invalidate cache, the book list may be stale for up to %s: %v", booksCacheTTL, err)
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"test-backend-altech/model/civil"
+	"test-backend-altech/model/domain"
+)
+
+// checkBirthDate rejects birth dates that have not happened yet.
+func checkBirthDate(birthDate civil.Date) error {
+	if birthDate.IsZero() || !birthDate.After(civil.Today()) {
+		return nil
+	}
+	return &domain.RuleViolationError{
+		Entity:  domain.EntityAuthor,
+		Rule:    domain.RuleBirthDateInFuture,
+		Field:   "birth_date",
+		Message: "Author birth_date can not be in the future",
+	}
+}
+
+// checkPublishDate rejects books published before their author was born.
+func checkPublishDate(publishDate civil.Date, authorBirthDate civil.Date) error {
+	if publishDate.IsZero() || authorBirthDate.IsZero() || !publishDate.Before(authorBirthDate) {
+		return nil
+	}
+	return &domain.RuleViolationError{
+		Entity:  domain.EntityBook,
+		Rule:    domain.RulePublishedBeforeAuthorBirth,
+		Field:   "publish_date",
+		Message: "Book publish_date can not be before the birth_date of its author",
+	}
+}
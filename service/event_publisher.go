@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"test-backend-altech/config"
+	response "test-backend-altech/model/web/response"
+)
+
+// EventPublisher delivers domain events from the outbox to consumers.
+// Publish may be called again for an event it already delivered, when the
+// relay failed to record the delivery, so consumers must drop duplicates by
+// the event id.
+type EventPublisher interface {
+	Publish(ctx context.Context, event response.EventMessage) error
+}
+
+// NewEventPublisher returns the publisher chosen by cfg.Publisher.
+func NewEventPublisher(cfg config.OutboxConfig, cache config.Cache) (EventPublisher, error) {
+	switch cfg.Publisher {
+	case config.OutboxPublisherStdout:
+		return NewWriterPublisher(os.Stdout), nil
+	case config.OutboxPublisherFile:
+		return NewFilePublisher(cfg.FilePath)
+	case config.OutboxPublisherRedis:
+		return NewRedisStreamPublisher(cache, cfg.RedisStream, cfg.RedisMaxLen), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+type writerPublisher struct {
+	mu   sync.Mutex
+	w    io.Writer
+	sync func() error
+}
+
+// NewWriterPublisher writes every event to w as one line of JSON.
+func NewWriterPublisher(w io.Writer) EventPublisher {
+	return &writerPublisher{w: w}
+}
+
+// NewFilePublisher appends every event to the file at path as one line of
+// JSON and syncs the file before reporting the event as published.
+func NewFilePublisher(path string) (EventPublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &writerPublisher{w: file, sync: file.Sync}, nil
+}
+
+func (p *writerPublisher) Publish(ctx context.Context, event response.EventMessage) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if p.sync != nil {
+		return p.sync()
+	}
+	return nil
+}
+
+type redisStreamPublisher struct {
+	cache  config.Cache
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamPublisher adds every event to a Redis stream. The entry
+// carries the event id, type and aggregate as separate fields, so consumer
+// groups can filter without decoding, and the whole event as JSON in event.
+func NewRedisStreamPublisher(cache config.Cache, stream string, maxLen int64) EventPublisher {
+	return &redisStreamPublisher{
+		cache:  cache,
+		stream: stream,
+		maxLen: maxLen,
+	}
+}
+
+func (p *redisStreamPublisher) Publish(ctx context.Context, event response.EventMessage) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.cache.XAdd(ctx, p.stream, p.maxLen, map[string]interface{}{
+		"id":             strconv.FormatInt(event.Id, 10),
+		"type":           event.Type,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateId,
+		"event":          body,
+	})
+	return err
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	response "test-backend-altech/model/web/response"
+)
+
+// versionDiff compares two recorded versions of a record the same way the
+// audit log compares the record before and after a change.
+func versionDiff(from int, to int, before interface{}, after interface{}) (response.VersionDiffResponse, error) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return response.VersionDiffResponse{}, err
+	}
+
+	data := response.VersionDiffResponse{
+		From:    from,
+		To:      to,
+		Changes: make(map[string]response.AuditChangeResponse, len(changes)),
+	}
+	for field, change := range changes {
+		data.Changes[field] = response.AuditChangeResponse{Before: change.Before, After: change.After}
+	}
+	return data, nil
+}
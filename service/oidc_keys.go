@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"test-backend-altech/config"
+)
+
+const (
+	oidcDiscoveryKey = "oidc:discovery"
+	oidcJWKSKey      = "oidc:jwks"
+	// oidcJWKSRefreshKey is set while a forced JWKS refresh is cooling down,
+	// so tokens with made up key ids can not make us hammer the provider.
+	oidcJWKSRefreshKey      = "oidc:jwks:refreshed"
+	oidcJWKSRefreshCooldown = time.Minute
+)
+
+// oidcDiscovery is the part of the provider's discovery document we use.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// oidcProvider reads the discovery document and signing keys of the
+// provider. Both are kept in the cache, so every instance of the API shares
+// them and the provider is only asked again when they expire or a token is
+// signed with a key we have not seen yet.
+type oidcProvider struct {
+	config config.OIDCConfig
+	cache  config.Cache
+	client *http.Client
+}
+
+func newOIDCProvider(config config.OIDCConfig, cache config.Cache) *oidcProvider {
+	return &oidcProvider{
+		config: config,
+		cache:  cache,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *oidcProvider) discovery(ctx context.Context) (oidcDiscovery, error) {
+	body, err := p.cached(ctx, oidcDiscoveryKey, func() ([]byte, error) {
+		return p.get(ctx, p.config.Issuer+"/.well-known/openid-configuration")
+	})
+	if err != nil {
+		return oidcDiscovery{}, err
+	}
+
+	var doc oidcDiscovery
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return oidcDiscovery{}, fmt.Errorf("oidc: bad discovery document: %w", err)
+	}
+	if doc.Issuer != p.config.Issuer {
+		return oidcDiscovery{}, fmt.Errorf("oidc: discovery issuer %q does not match %q", doc.Issuer, p.config.Issuer)
+	}
+	return doc, nil
+}
+
+// publicKey returns the signing key with the given id, fetching the key set
+// again once if the cached one does not contain it.
+func (p *oidcProvider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	keys, err := p.keySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := keys.find(kid); ok {
+		return key.publicKey()
+	}
+
+	if _, err := p.cache.Get(ctx, oidcJWKSRefreshKey); err == nil {
+		return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+	}
+	if err := p.cache.Set(ctx, oidcJWKSRefreshKey, []byte("1"), oidcJWKSRefreshCooldown); err != nil {
+		return nil, err
+	}
+	if keys, err = p.keySet(ctx, true); err != nil {
+		return nil, err
+	}
+	if key, ok := keys.find(kid); ok {
+		return key.publicKey()
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+func (p *oidcProvider) keySet(ctx context.Context, refresh bool) (jsonWebKeySet, error) {
+	fetch := func() ([]byte, error) {
+		doc, err := p.discovery(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return p.get(ctx, doc.JWKSURI)
+	}
+
+	var body []byte
+	var err error
+	if refresh {
+		if body, err = fetch(); err == nil {
+			err = p.cache.Set(ctx, oidcJWKSKey, body, p.config.JWKSCacheTTL)
+		}
+	} else {
+		body, err = p.cached(ctx, oidcJWKSKey, fetch)
+	}
+	if err != nil {
+		return jsonWebKeySet{}, err
+	}
+
+	var keys jsonWebKeySet
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return jsonWebKeySet{}, fmt.Errorf("oidc: bad key set: %w", err)
+	}
+	return keys, nil
+}
+
+// cached returns the cached value of key, or stores what fetch returns.
+func (p *oidcProvider) cached(ctx context.Context, key string, fetch func() ([]byte, error)) ([]byte, error) {
+	body, err := p.cache.Get(ctx, key)
+	if err == nil {
+		return body, nil
+	}
+	if !errors.Is(err, config.ErrCacheMiss) {
+		return nil, err
+	}
+
+	if body, err = fetch(); err != nil {
+		return nil, err
+	}
+	if err := p.cache.Set(ctx, key, body, p.config.JWKSCacheTTL); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (p *oidcProvider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: GET %s answered %d", url, res.StatusCode)
+	}
+	return body, nil
+}
+
+func (keys jsonWebKeySet) find(kid string) (jsonWebKey, bool) {
+	for _, key := range keys.Keys {
+		if key.Kid == kid && (key.Use == "" || key.Use == "sig") {
+			return key, true
+		}
+	}
+	return jsonWebKey{}, false
+}
+
+func (key jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("oidc: key %q has a bad exponent", key.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: key %q uses unsupported curve %q", key.Kid, key.Crv)
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("oidc: key %q has unsupported type %q", key.Kid, key.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: bad key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
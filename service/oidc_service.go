@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// oidcStateTTL is how long a login started with AuthorizationURL can be
+// completed.
+const oidcStateTTL = 10 * time.Minute
+
+// unusablePasswordHash is stored for users created from the identity
+// provider. It is no bcrypt hash, so password login always fails for them.
+const unusablePasswordHash = "!"
+
+// errOIDCAccountTaken refuses a login whose email belongs to a user that
+// may not be linked to the identity.
+var errOIDCAccountTaken = &domain.UnauthenticatedError{
+	Reason: domain.ReasonInvalidCredentials,
+	Err:    errors.New("oidc: email belongs to an account that is not linked to this identity"),
+}
+
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcLogin is kept in the cache between redirecting to the provider and the
+// callback.
+type oidcLogin struct {
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type OIDCService interface {
+	// AuthorizationURL starts an authorization code login with PKCE.
+	AuthorizationURL(ctx context.Context) (string, error)
+	// Callback completes a login and returns tokens of this API.
+	Callback(ctx context.Context, request request.OIDCCallbackRequest) (response.TokenResponse, error)
+	// Accepts reports whether token was issued by the identity provider.
+	Accepts(token string) bool
+	// Authenticate validates an access token of the identity provider.
+	Authenticate(ctx context.Context, token string) (domain.Principal, error)
+}
+
+type oidcService struct {
+	config         config.OIDCConfig
+	cache          config.Cache
+	provider       *oidcProvider
+	userRepository repository.UserRepository
+	roleRepository repository.RoleRepository
+	uow            repository.UnitOfWork
+	tokenService   TokenService
+}
+
+func NewOIDCService(config config.OIDCConfig, cache config.Cache, userRepository repository.UserRepository, roleRepository repository.RoleRepository, uow repository.UnitOfWork, tokenService TokenService) OIDCService {
+	return &oidcService{
+		config:         config,
+		cache:          cache,
+		provider:       newOIDCProvider(config, cache),
+		userRepository: userRepository,
+		roleRepository: roleRepository,
+		uow:            uow,
+		tokenService:   tokenService,
+	}
+}
+
+func (s *oidcService) AuthorizationURL(ctx context.Context) (string, error) {
+	doc, err := s.provider.discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	login := oidcLogin{}
+	if login.Verifier, err = randomString(32, base64.RawURLEncoding.EncodeToString); err != nil {
+		return "", err
+	}
+	if login.Nonce, err = randomString(32, base64.RawURLEncoding.EncodeToString); err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(login)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, oidcStateKey(state), body, oidcStateTTL); err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.config.ClientID},
+		"redirect_uri":          {s.config.RedirectURL},
+		"scope":                 {strings.Join(s.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Callback trades the authorization code for an ID token, creates the user
+// on its first login and replaces the roles of users created that way with
+// the ones its groups map to, so the provider stays the source of truth for
+// who may do what. Linked local accounts keep the roles given here.
+func (s *oidcService) Callback(ctx context.Context, request request.OIDCCallbackRequest) (response.TokenResponse, error) {
+	if request.Error != "" {
+		return response.TokenResponse{}, &domain.UnauthenticatedError{
+			Reason: domain.ReasonInvalidCredentials,
+			Err:    fmt.Errorf("oidc: provider answered %s: %s", request.Error, request.ErrorDescription),
+		}
+	}
+
+	body, err := s.cache.GetDel(ctx, oidcStateKey(request.State))
+	if errors.Is(err, config.ErrCacheMiss) {
+		return response.TokenResponse{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: err}
+	}
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	var login oidcLogin
+	if err := json.Unmarshal(body, &login); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	idToken, err := s.exchangeCode(ctx, request.Code, login.Verifier)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	claims, err := s.parse(ctx, idToken, s.config.ClientID)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != login.Nonce {
+		return response.TokenResponse{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken}
+	}
+
+	identity, err := oidcIdentityOf(claims)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	roles := s.mapRoles(claims)
+
+	user, err := s.provisionUser(ctx, identity, roles)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if oidcManaged(user) {
+		if err := s.roleRepository.SetUserRoles(ctx, user.Id, roles); err != nil {
+			return response.TokenResponse{}, err
+		}
+	}
+	return s.tokenService.IssueTokens(ctx, user, "")
+}
+
+func (s *oidcService) Accepts(token string) bool {
+	if s.config.Issuer == "" {
+		return false
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return false
+	}
+	issuer, _ := claims.GetIssuer()
+	return issuer == s.config.Issuer
+}
+
+// Authenticate validates an access token the provider issued for this API.
+// A user is created for the caller on first use so its writes can be
+// attributed. Users created that way hold the permissions of the roles their
+// groups map to, linked local accounts the permissions of their own roles.
+func (s *oidcService) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	claims, err := s.parse(ctx, token, s.config.Audience)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	identity, err := oidcIdentityOf(claims)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	roles := s.mapRoles(claims)
+
+	user, err := s.provisionUser(ctx, identity, roles)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if !oidcManaged(user) {
+		permissions, err := s.roleRepository.FindUserPermissions(ctx, user.Id)
+		if err != nil {
+			return domain.Principal{}, err
+		}
+		return domain.Principal{UserId: user.Id, Email: user.Email, Permissions: permissions}, nil
+	}
+
+	var permissions []string
+	for _, name := range roles {
+		role, err := s.roleRepository.FindRole(ctx, name)
+		var notFoundErr *domain.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			continue
+		}
+		if err != nil {
+			return domain.Principal{}, err
+		}
+		for _, permission := range role.Permissions {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return domain.Principal{UserId: user.Id, Email: user.Email, Permissions: permissions}, nil
+}
+
+func (s *oidcService) parse(ctx context.Context, token string, audience string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(t *jwt.Token) (interface{}, error) {
+			kid, _ := t.Header["kid"].(string)
+			return s.provider.publicKey(ctx, kid)
+		},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(s.config.Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, &domain.UnauthenticatedError{Reason: domain.ReasonTokenExpired, Err: err}
+	}
+	if err != nil {
+		return nil, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: err}
+	}
+	return claims, nil
+}
+
+func (s *oidcService) exchangeCode(ctx context.Context, code string, verifier string) (string, error) {
+	doc, err := s.provider.discovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.config.RedirectURL},
+		"client_id":     {s.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	if s.config.ClientSecret != "" {
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.provider.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc: %w", err)
+	}
+	defer res.Body.Close()
+
+	var tokens struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("oidc: bad token response: %w", err)
+	}
+	// A rejected code is the caller's problem, anything else is ours.
+	if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized {
+		return "", &domain.UnauthenticatedError{
+			Reason: domain.ReasonInvalidCredentials,
+			Err:    fmt.Errorf("oidc: token endpoint answered %s: %s", tokens.Error, tokens.ErrorDescription),
+		}
+	}
+	if res.StatusCode != http.StatusOK || tokens.IdToken == "" {
+		return "", fmt.Errorf("oidc: token endpoint answered %d without an id_token", res.StatusCode)
+	}
+	return tokens.IdToken, nil
+}
+
+// provisionUser returns the user linked to the provider identity. On the
+// first login of an identity the user with the same email is linked to it,
+// see canLink, and a user with roles is created when the email is unknown.
+func (s *oidcService) provisionUser(ctx context.Context, identity oidcIdentity, roles []string) (domain.User, error) {
+	user, err := s.userRepository.FindByOIDCSubject(ctx, s.config.Issuer, identity.Subject)
+	var notFoundErr *domain.NotFoundError
+	if !errors.As(err, &notFoundErr) {
+		return user, err
+	}
+
+	user, err = s.userRepository.FindByEmail(ctx, identity.Email)
+	if err == nil {
+		if !s.canLink(user) {
+			return domain.User{}, errOIDCAccountTaken
+		}
+		err := s.userRepository.LinkOIDCSubject(ctx, user.Id, s.config.Issuer, identity.Subject)
+		if errors.As(err, &notFoundErr) {
+			// Another login linked the user meanwhile.
+			return domain.User{}, errOIDCAccountTaken
+		}
+		if err != nil {
+			return domain.User{}, err
+		}
+		return s.userRepository.FindByID(ctx, user.Id)
+	}
+	if !errors.As(err, &notFoundErr) {
+		return domain.User{}, err
+	}
+
+	user = domain.User{
+		Email:        identity.Email,
+		Name:         identity.Name,
+		PasswordHash: unusablePasswordHash,
+		OIDCIssuer:   &s.config.Issuer,
+		OIDCSubject:  &identity.Subject,
+	}
+	user.GenerateID()
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.roleRepository.SetUserRoles(ctx, user.Id, roles)
+	})
+	// Two first requests of the same user may race to create it, the loser
+	// reads the winner's user.
+	var conflictErr *domain.ConflictError
+	if err != nil && !errors.As(err, &conflictErr) {
+		return domain.User{}, err
+	}
+	user, err = s.userRepository.FindByOIDCSubject(ctx, s.config.Issuer, identity.Subject)
+	if errors.As(err, &notFoundErr) {
+		// The email was taken by another user meanwhile.
+		return domain.User{}, errOIDCAccountTaken
+	}
+	return user, err
+}
+
+// canLink reports whether a user without this provider identity may be
+// linked to it. Users created by OIDC before identities were recorded are
+// linked; local accounts, which have a password, only when the configuration
+// allows it; users linked to another identity never.
+func (s *oidcService) canLink(user domain.User) bool {
+	if user.OIDCSubject != nil {
+		return false
+	}
+	return oidcManaged(user) || s.config.LinkLocalUsers
+}
+
+// oidcManaged reports whether the user was created by an OIDC login, which
+// makes the provider the source of its roles.
+func oidcManaged(user domain.User) bool {
+	return user.PasswordHash == unusablePasswordHash
+}
+
+// mapRoles returns the roles the groups of the token map to.
+func (s *oidcService) mapRoles(claims jwt.MapClaims) []string {
+	var groups []string
+	switch value := claims[s.config.GroupsClaim].(type) {
+	case string:
+		groups = strings.Fields(value)
+	case []interface{}:
+		for _, group := range value {
+			if group, ok := group.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	var roles []string
+	for _, group := range groups {
+		for _, role := range s.config.GroupRoles[group] {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if len(roles) == 0 && s.config.DefaultRole != "" {
+		roles = []string{s.config.DefaultRole}
+	}
+	return roles
+}
+
+// oidcIdentity is who a token of the provider names.
+type oidcIdentity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// oidcIdentityOf reads the identity of a token. A first login is matched by
+// email, so the provider has to state that the email is verified.
+func oidcIdentityOf(claims jwt.MapClaims) (oidcIdentity, error) {
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return oidcIdentity{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: errors.New("oidc: token has no subject")}
+	}
+	email, _ := claims["email"].(string)
+	if email == "" {
+		return oidcIdentity{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: errors.New("oidc: token has no email")}
+	}
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return oidcIdentity{}, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: errors.New("oidc: email is not verified")}
+	}
+	name, _ := claims["name"].(string)
+	return oidcIdentity{Subject: subject, Email: email, Name: name}, nil
+}
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
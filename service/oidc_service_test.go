@@ -0,0 +1,681 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// testIdP is an identity provider serving discovery, JWKS and a token
+// endpoint. Codes are handed out by authorize, which plays the part of the
+// user's browser at the authorization endpoint.
+type testIdP struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu       sync.Mutex
+	key      *rsa.PrivateKey
+	kid      string
+	jwksHits int
+	codes    map[string]testAuthorization
+	idClaims func(nonce string) jwt.MapClaims
+}
+
+type testAuthorization struct {
+	challenge string
+	nonce     string
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	idp := &testIdP{t: t, codes: map[string]testAuthorization{}}
+	idp.rotateKey("key-1")
+	idp.idClaims = func(nonce string) jwt.MapClaims {
+		return idp.claims(jwt.MapClaims{"aud": "client", "nonce": nonce})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.jwksHits++
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": idp.kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+			return
+		}
+		idp.mu.Lock()
+		authorization, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != authorization.challenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id_token": idp.sign(idp.idClaims(authorization.nonce))})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (idp *testIdP) jwksFetches() int {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	return idp.jwksHits
+}
+
+func (idp *testIdP) rotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key, idp.kid = key, kid
+}
+
+// claims returns valid claims for the test user, overridden by extra.
+func (idp *testIdP) claims(extra jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "subject-1",
+		"aud":            "api",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane",
+		"groups":         []string{"editors"},
+	}
+	for name, value := range extra {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func (idp *testIdP) sign(claims jwt.MapClaims) string {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	return idp.signWith(idp.key, idp.kid, claims)
+}
+
+func (idp *testIdP) signWith(key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed
+}
+
+// authorize follows the authorization URL like a browser would and returns
+// the callback the provider redirects to.
+func (idp *testIdP) authorize(authorizationURL string) request.OIDCCallbackRequest {
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		idp.t.Fatalf("authorization URL without S256 challenge: %s", authorizationURL)
+	}
+	code, err := randomString(16, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.codes[code] = testAuthorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return request.OIDCCallbackRequest{Code: code, State: query.Get("state")}
+}
+
+type testCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newTestCache() *testCache {
+	return &testCache{values: map[string][]byte{}}
+}
+
+func (c *testCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil, config.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (c *testCache) GetDel(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return nil, config.ErrCacheMiss
+	}
+	delete(c.values, key)
+	return value, nil
+}
+
+func (c *testCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *testCache) SetNX(ctx context.Context, key string, value []byte, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = value
+	return true, nil
+}
+
+func (c *testCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func (c *testCache) DeletePattern(ctx context.Context, pattern string) error {
+	return errors.New("not supported")
+}
+
+func (c *testCache) Eval(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testCache) XAdd(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return "", errors.New("not supported")
+}
+
+type testUserRepository struct {
+	users map[string]domain.User
+}
+
+func (r *testUserRepository) CreateUser(c context.Context, user domain.User) error {
+	if _, err := r.FindByEmail(c, user.Email); err == nil {
+		return &domain.ConflictError{Entity: domain.EntityUser, Field: "email"}
+	}
+	r.users[user.Id] = user
+	return nil
+}
+
+func (r *testUserRepository) FindByID(c context.Context, id string) (domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, &domain.NotFoundError{Entity: domain.EntityUser}
+	}
+	return user, nil
+}
+
+func (r *testUserRepository) FindByEmail(c context.Context, email string) (domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return domain.User{}, &domain.NotFoundError{Entity: domain.EntityUser}
+}
+
+func (r *testUserRepository) FindByOIDCSubject(c context.Context, issuer string, subject string) (domain.User, error) {
+	for _, user := range r.users {
+		if user.OIDCIssuer != nil && *user.OIDCIssuer == issuer && *user.OIDCSubject == subject {
+			return user, nil
+		}
+	}
+	return domain.User{}, &domain.NotFoundError{Entity: domain.EntityUser}
+}
+
+func (r *testUserRepository) LinkOIDCSubject(c context.Context, id string, issuer string, subject string) error {
+	user, ok := r.users[id]
+	if !ok || user.OIDCSubject != nil {
+		return &domain.NotFoundError{Entity: domain.EntityUser}
+	}
+	user.OIDCIssuer, user.OIDCSubject = &issuer, &subject
+	r.users[id] = user
+	return nil
+}
+
+func (r *testUserRepository) CountUsers(c context.Context) (int64, error) {
+	return int64(len(r.users)), nil
+}
+
+type testRoleRepository struct {
+	roles     map[string]domain.Role
+	userRoles map[string][]string
+}
+
+func (r *testRoleRepository) FindAllRoles(c context.Context) ([]domain.Role, error) {
+	return nil, errors.New("not supported")
+}
+
+func (r *testRoleRepository) FindRole(c context.Context, name string) (domain.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return domain.Role{}, &domain.NotFoundError{Entity: domain.EntityRole}
+	}
+	return role, nil
+}
+
+func (r *testRoleRepository) CreateRole(c context.Context, role domain.Role) error {
+	return errors.New("not supported")
+}
+
+func (r *testRoleRepository) DeleteRole(c context.Context, name string) error {
+	return errors.New("not supported")
+}
+
+func (r *testRoleRepository) SetRolePermissions(c context.Context, name string, permissions []string) error {
+	return errors.New("not supported")
+}
+
+func (r *testRoleRepository) FindAllPermissions(c context.Context) ([]domain.Permission, error) {
+	return nil, errors.New("not supported")
+}
+
+func (r *testRoleRepository) FindUserRoles(c context.Context, userId string) ([]string, error) {
+	return r.userRoles[userId], nil
+}
+
+func (r *testRoleRepository) SetUserRoles(c context.Context, userId string, roles []string) error {
+	r.userRoles[userId] = roles
+	return nil
+}
+
+func (r *testRoleRepository) FindUserPermissions(c context.Context, userId string) ([]string, error) {
+	var permissions []string
+	for _, name := range r.userRoles[userId] {
+		permissions = append(permissions, r.roles[name].Permissions...)
+	}
+	return permissions, nil
+}
+
+type testUnitOfWork struct{}
+
+func (testUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (testUnitOfWork) DoWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// testTokenService issues the id of the user as its access token.
+type testTokenService struct{}
+
+func (testTokenService) IssueTokens(ctx context.Context, user domain.User, family string) (response.TokenResponse, error) {
+	return response.TokenResponse{AccessToken: user.Id}, nil
+}
+
+func (testTokenService) ConsumeRefreshToken(ctx context.Context, token string) (string, string, error) {
+	return "", "", errors.New("not supported")
+}
+
+func (testTokenService) RevokeRefreshToken(ctx context.Context, token string) error {
+	return errors.New("not supported")
+}
+
+func (testTokenService) ParseAccessToken(token string) (domain.Principal, error) {
+	return domain.Principal{}, errors.New("not supported")
+}
+
+type oidcTest struct {
+	idp     *testIdP
+	cache   *testCache
+	users   *testUserRepository
+	roles   *testRoleRepository
+	service *oidcService
+}
+
+func newOIDCTest(t *testing.T, configure func(cfg *config.OIDCConfig)) *oidcTest {
+	idp := newTestIdP(t)
+	cfg := config.OIDCConfig{
+		Issuer:       idp.server.URL,
+		ClientID:     "client",
+		RedirectURL:  "http://localhost/auth/oidc/callback",
+		Audience:     "api",
+		Scopes:       []string{"openid", "email"},
+		GroupsClaim:  "groups",
+		GroupRoles:   map[string][]string{"editors": {"editor"}, "admins": {"admin", "editor"}},
+		DefaultRole:  "reader",
+		JWKSCacheTTL: time.Hour,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	test := &oidcTest{
+		idp:   idp,
+		cache: newTestCache(),
+		users: &testUserRepository{users: map[string]domain.User{}},
+		roles: &testRoleRepository{
+			roles: map[string]domain.Role{
+				"reader": {Name: "reader", Permissions: []string{"authors.read"}},
+				"editor": {Name: "editor", Permissions: []string{"authors.read", "authors.update"}},
+				"admin":  {Name: "admin", Permissions: []string{"roles.manage"}},
+			},
+			userRoles: map[string][]string{},
+		},
+	}
+	test.service = NewOIDCService(cfg, test.cache, test.users, test.roles, testUnitOfWork{}, testTokenService{}).(*oidcService)
+	return test
+}
+
+func (test *oidcTest) login(t *testing.T) (response.TokenResponse, error) {
+	authorizationURL, err := test.service.AuthorizationURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return test.service.Callback(context.Background(), test.idp.authorize(authorizationURL))
+}
+
+func requireUnauthenticated(t *testing.T, err error, reason string) {
+	t.Helper()
+	var unauthenticatedErr *domain.UnauthenticatedError
+	if !errors.As(err, &unauthenticatedErr) {
+		t.Fatalf("got error %v, want an UnauthenticatedError", err)
+	}
+	if unauthenticatedErr.Reason != reason {
+		t.Fatalf("got reason %q, want %q", unauthenticatedErr.Reason, reason)
+	}
+}
+
+func TestOIDCCallbackCreatesUser(t *testing.T) {
+	test := newOIDCTest(t, nil)
+
+	tokens, err := test.login(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := test.users.users[tokens.AccessToken]
+	if user.Email != "jane@example.com" || user.PasswordHash != unusablePasswordHash {
+		t.Fatalf("got user %+v", user)
+	}
+	if user.OIDCSubject == nil || *user.OIDCSubject != "subject-1" || *user.OIDCIssuer != test.idp.server.URL {
+		t.Fatalf("user is not linked to the identity: %+v", user)
+	}
+	if roles := test.roles.userRoles[user.Id]; !slices.Equal(roles, []string{"editor"}) {
+		t.Fatalf("got roles %v, want [editor]", roles)
+	}
+}
+
+func TestOIDCCallbackVerifierBelongsToState(t *testing.T) {
+	test := newOIDCTest(t, nil)
+
+	first, err := test.service.AuthorizationURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := test.service.AuthorizationURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The code was issued for the challenge of the first login, the state
+	// holds the verifier of the second one.
+	callback := test.idp.authorize(first)
+	callback.State = test.idp.authorize(second).State
+
+	_, err = test.service.Callback(context.Background(), callback)
+	requireUnauthenticated(t, err, domain.ReasonInvalidCredentials)
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	test := newOIDCTest(t, nil)
+
+	authorizationURL, err := test.service.AuthorizationURL(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback := test.idp.authorize(authorizationURL)
+
+	unknown := callback
+	unknown.State = "unknown"
+	_, err = test.service.Callback(context.Background(), unknown)
+	requireUnauthenticated(t, err, domain.ReasonInvalidToken)
+
+	if _, err := test.service.Callback(context.Background(), callback); err != nil {
+		t.Fatal(err)
+	}
+	_, err = test.service.Callback(context.Background(), callback)
+	requireUnauthenticated(t, err, domain.ReasonInvalidToken)
+}
+
+func TestOIDCCallbackNonce(t *testing.T) {
+	test := newOIDCTest(t, nil)
+	test.idp.idClaims = func(nonce string) jwt.MapClaims {
+		return test.idp.claims(jwt.MapClaims{"aud": "client", "nonce": "replayed"})
+	}
+
+	_, err := test.login(t)
+	requireUnauthenticated(t, err, domain.ReasonInvalidToken)
+	if len(test.users.users) != 0 {
+		t.Fatal("user created for a token with the wrong nonce")
+	}
+}
+
+func TestOIDCAuthenticateRejectsTokens(t *testing.T) {
+	test := newOIDCTest(t, nil)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		reason string
+	}{
+		{"wrong audience", test.idp.sign(test.idp.claims(jwt.MapClaims{"aud": "other-api"})), domain.ReasonInvalidToken},
+		{"id token audience", test.idp.sign(test.idp.claims(jwt.MapClaims{"aud": "client"})), domain.ReasonInvalidToken},
+		{"wrong issuer", test.idp.sign(test.idp.claims(jwt.MapClaims{"iss": "https://evil.example.com"})), domain.ReasonInvalidToken},
+		{"expired", test.idp.sign(test.idp.claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})), domain.ReasonTokenExpired},
+		{"no expiry", test.idp.sign(test.idp.claims(jwt.MapClaims{"exp": nil})), domain.ReasonInvalidToken},
+		{"wrong signature", test.idp.signWith(other, test.idp.kid, test.idp.claims(nil)), domain.ReasonInvalidToken},
+		{"no subject", test.idp.sign(test.idp.claims(jwt.MapClaims{"sub": nil})), domain.ReasonInvalidToken},
+		{"unverified email", test.idp.sign(test.idp.claims(jwt.MapClaims{"email_verified": false})), domain.ReasonInvalidToken},
+		{"email not known to be verified", test.idp.sign(test.idp.claims(jwt.MapClaims{"email_verified": nil})), domain.ReasonInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := test.service.Authenticate(context.Background(), tt.token)
+			requireUnauthenticated(t, err, tt.reason)
+		})
+	}
+	if len(test.users.users) != 0 {
+		t.Fatal("user created for a rejected token")
+	}
+}
+
+func TestOIDCAuthenticateRefreshesKeys(t *testing.T) {
+	test := newOIDCTest(t, nil)
+
+	if _, err := test.service.Authenticate(context.Background(), test.idp.sign(test.idp.claims(nil))); err != nil {
+		t.Fatal(err)
+	}
+	if test.idp.jwksFetches() != 1 {
+		t.Fatalf("got %d JWKS fetches, want 1", test.idp.jwksFetches())
+	}
+
+	// The provider rotated its key, the cached key set does not know it yet.
+	test.idp.rotateKey("key-2")
+	if _, err := test.service.Authenticate(context.Background(), test.idp.sign(test.idp.claims(nil))); err != nil {
+		t.Fatal(err)
+	}
+	if test.idp.jwksFetches() != 2 {
+		t.Fatalf("got %d JWKS fetches, want 2", test.idp.jwksFetches())
+	}
+
+	// Within the cooldown an unknown key id does not reach the provider.
+	_, err := test.service.Authenticate(context.Background(), test.idp.signWith(test.idp.key, "made-up", test.idp.claims(nil)))
+	requireUnauthenticated(t, err, domain.ReasonInvalidToken)
+	if test.idp.jwksFetches() != 2 {
+		t.Fatalf("got %d JWKS fetches during the cooldown, want 2", test.idp.jwksFetches())
+	}
+
+	// Once it is over the key set is fetched again.
+	if err := test.cache.Delete(context.Background(), oidcJWKSRefreshKey); err != nil {
+		t.Fatal(err)
+	}
+	_, err = test.service.Authenticate(context.Background(), test.idp.signWith(test.idp.key, "made-up", test.idp.claims(nil)))
+	requireUnauthenticated(t, err, domain.ReasonInvalidToken)
+	if test.idp.jwksFetches() != 3 {
+		t.Fatalf("got %d JWKS fetches after the cooldown, want 3", test.idp.jwksFetches())
+	}
+}
+
+func TestOIDCAuthenticateMapsGroups(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      interface{}
+		permissions []string
+	}{
+		{"mapped group", []string{"editors", "unmapped"}, []string{"authors.read", "authors.update"}},
+		{"group with several roles", []string{"admins"}, []string{"roles.manage", "authors.read", "authors.update"}},
+		{"space separated groups", "unmapped admins", []string{"roles.manage", "authors.read", "authors.update"}},
+		{"no mapped group", []string{"unmapped"}, []string{"authors.read"}},
+		{"no groups", nil, []string{"authors.read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := newOIDCTest(t, nil)
+			token := test.idp.sign(test.idp.claims(jwt.MapClaims{"groups": tt.groups}))
+
+			principal, err := test.service.Authenticate(context.Background(), token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(principal.Permissions, tt.permissions) {
+				t.Fatalf("got permissions %v, want %v", principal.Permissions, tt.permissions)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackReplacesRolesOfProvisionedUsers(t *testing.T) {
+	test := newOIDCTest(t, nil)
+	tokens, err := test.login(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.idp.idClaims = func(nonce string) jwt.MapClaims {
+		return test.idp.claims(jwt.MapClaims{"aud": "client", "nonce": nonce, "groups": []string{"admins"}})
+	}
+	if _, err := test.login(t); err != nil {
+		t.Fatal(err)
+	}
+	if roles := test.roles.userRoles[tokens.AccessToken]; !slices.Equal(roles, []string{"admin", "editor"}) {
+		t.Fatalf("got roles %v, want [admin editor]", roles)
+	}
+}
+
+func TestOIDCDoesNotLinkLocalAccounts(t *testing.T) {
+	test := newOIDCTest(t, nil)
+	test.users.users["local"] = domain.User{Id: "local", Email: "jane@example.com", PasswordHash: "$2a$10$hash"}
+	test.roles.userRoles["local"] = []string{"admin"}
+
+	_, err := test.login(t)
+	requireUnauthenticated(t, err, domain.ReasonInvalidCredentials)
+	_, err = test.service.Authenticate(context.Background(), test.idp.sign(test.idp.claims(nil)))
+	requireUnauthenticated(t, err, domain.ReasonInvalidCredentials)
+
+	if user := test.users.users["local"]; user.OIDCSubject != nil {
+		t.Fatal("local account was linked")
+	}
+	if roles := test.roles.userRoles["local"]; !slices.Equal(roles, []string{"admin"}) {
+		t.Fatalf("roles of the local account changed to %v", roles)
+	}
+}
+
+func TestOIDCLinksLocalAccountsWhenConfigured(t *testing.T) {
+	test := newOIDCTest(t, func(cfg *config.OIDCConfig) { cfg.LinkLocalUsers = true })
+	test.users.users["local"] = domain.User{Id: "local", Email: "jane@example.com", PasswordHash: "$2a$10$hash"}
+	test.roles.userRoles["local"] = []string{"admin"}
+
+	tokens, err := test.login(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken != "local" {
+		t.Fatalf("logged in as %q, want the local account", tokens.AccessToken)
+	}
+	if roles := test.roles.userRoles["local"]; !slices.Equal(roles, []string{"admin"}) {
+		t.Fatalf("roles of the local account changed to %v", roles)
+	}
+
+	principal, err := test.service.Authenticate(context.Background(), test.idp.sign(test.idp.claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.UserId != "local" || !slices.Equal(principal.Permissions, []string{"roles.manage"}) {
+		t.Fatalf("got principal %+v, want the local account with its own permissions", principal)
+	}
+}
+
+func TestOIDCDoesNotRelinkToAnotherSubject(t *testing.T) {
+	test := newOIDCTest(t, nil)
+	if _, err := test.login(t); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another identity at the provider now claims the same email.
+	token := test.idp.sign(test.idp.claims(jwt.MapClaims{"sub": "subject-2"}))
+	_, err := test.service.Authenticate(context.Background(), token)
+	requireUnauthenticated(t, err, domain.ReasonInvalidCredentials)
+}
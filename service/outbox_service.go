@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+	"test-backend-altech/repository"
+
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+type OutboxService interface {
+	Relay(ctx context.Context) (int, error)
+	Cleanup(ctx context.Context, before time.Time) (int64, error)
+}
+
+type outboxService struct {
+	outboxRepository repository.OutboxRepository
+	uow              repository.UnitOfWork
+	publisher        EventPublisher
+	config           config.OutboxConfig
+}
+
+func NewOutboxService(outboxRepository repository.OutboxRepository, uow repository.UnitOfWork, publisher EventPublisher, config config.OutboxConfig) OutboxService {
+	return &outboxService{
+		outboxRepository: outboxRepository,
+		uow:              uow,
+		publisher:        publisher,
+		config:           config,
+	}
+}
+
+// Relay publishes one batch of pending events in outbox order and returns
+// how many were published. Events are marked as published in the same
+// transaction, so an event is published again when that transaction does
+// not commit. When an event fails, the later events of the same entity are
+// held back until it is published, which keeps every entity in order, or
+// until it failed config.MaxAttempts times and is dead lettered.
+func (s *outboxService) Relay(ctx context.Context) (int, error) {
+	var published []int64
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		published = nil
+
+		locked, err := s.outboxRepository.LockOutbox(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		events, err := s.outboxRepository.FindPendingOutboxEvents(ctx, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		held := map[string]bool{}
+		for _, event := range events {
+			aggregate := event.AggregateType + ":" + event.AggregateId
+			if held[aggregate] {
+				continue
+			}
+			if err := s.publisher.Publish(ctx, event.ToEventMessage()); err != nil {
+				held[aggregate] = true
+				deadLettered, recordErr := s.outboxRepository.RecordOutboxFailure(ctx, event.Id, err.Error(), s.config.MaxAttempts)
+				if recordErr != nil {
+					return recordErr
+				}
+				if deadLettered {
+					log.Printf("Dead lettered event %d (%s) of %s after %d attempts: %v", event.Id, event.Type, aggregate, event.Attempts+1, err)
+				} else {
+					log.Printf("Failed to publish event %d (%s), attempt %d: %v", event.Id, event.Type, event.Attempts+1, err)
+				}
+				continue
+			}
+			published = append(published, event.Id)
+		}
+
+		if len(published) == 0 {
+			return nil
+		}
+		return s.outboxRepository.MarkOutboxEventsPublished(ctx, published)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// Cleanup removes events published before the given time.
+func (s *outboxService) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	return s.outboxRepository.DeletePublishedOutboxEvents(ctx, before)
+}
+
+// recordEvent writes a domain event about an author or book to the outbox.
+// Without a unit of work in ctx it fails, an event written apart from its
+// change could announce one that was rolled back. payload is the record after
+// the change, or before it for deletes.
+func recordEvent(ctx context.Context, outboxRepository repository.OutboxRepository, eventType string, aggregateType string, aggregateId string, payload interface{}) error {
+	if !repository.InUnitOfWork(ctx) {
+		return repository.ErrNoUnitOfWork
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event := domain.OutboxEvent{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Payload:       body,
+	}
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		event.ActorId = &principal.UserId
+	}
+	event.RequestId, _ = ctx.Value(requestid.ConfigDefault.ContextKey).(string)
+
+	return outboxRepository.CreateOutboxEvent(ctx, event)
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"test-backend-altech/model/domain"
+)
+
+// checkOwnership lets callers update records they created themselves, and
+// any record when they hold anyPermission. Records with an unknown creator
+// need anyPermission. Calls without a caller come from the service itself
+// and are not restricted.
+func checkOwnership(ctx context.Context, createdBy *string, anyPermission string) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.Can(anyPermission) {
+		return nil
+	}
+	if createdBy != nil && *createdBy == principal.UserId {
+		return nil
+	}
+	return &domain.ForbiddenError{Permission: anyPermission}
+}
+
+// checkPermission makes sure the caller holds permission, for work a route
+// does beyond what its own permission covers. Calls without a caller are not
+// restricted, as in checkOwnership.
+func checkPermission(ctx context.Context, permission string) error {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.Can(permission) {
+		return nil
+	}
+	return &domain.ForbiddenError{Permission: permission}
+}
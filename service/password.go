@@ -0,0 +1,28 @@
+package service
+
+import (
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordHashCost = 12
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// dummyPasswordHash is compared against when a login names an unknown user,
+// so both cases take as long and do not reveal which emails exist.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), passwordHashCost)
+	return hash
+})
+
+func checkPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
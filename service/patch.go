@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"test-backend-altech/exception"
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// applyPatch applies patch to the JSON form of current and decodes the result
+// into target. Fields the request type does not know are rejected.
+func applyPatch(current interface{}, patch request.PatchRequest, target interface{}) error {
+	document, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch patch.MediaType {
+	case request.JSONPatchMediaType:
+		operations, err := jsonpatch.DecodePatch(patch.Document)
+		if err != nil {
+			return &domain.InvalidInputError{Reason: "Invalid JSON patch document", Err: err}
+		}
+		patched, err = operations.Apply(document)
+		if err != nil {
+			return exception.ErrUnprocessableEntity(err.Error())
+		}
+	default:
+		patched, err = jsonpatch.MergePatch(document, patch.Document)
+		if err != nil {
+			return &domain.InvalidInputError{Reason: "Invalid merge patch document", Err: err}
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return exception.ErrValidateBadRequest(err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"test-backend-altech/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript takes ARGV[3] tokens, one or none, from the bucket in
+// KEYS[1], which holds ARGV[1] tokens and refills completely within ARGV[2]
+// milliseconds. The clock of Redis is used, so every instance of the API
+// agrees on the time; calling TIME before a write needs Redis 5 or newer.
+// It returns whether the request is allowed, the tokens left, the
+// milliseconds until a token is available and until the bucket is full.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rate = limit / window
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or limit
+local ts = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - cost
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+return {allowed, math.floor(tokens), retry, math.ceil((limit - tokens) / rate)}
+`)
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+type RateLimiter interface {
+	// Allow takes one request of subject from the bucket of policy.
+	Allow(ctx context.Context, policy config.RateLimitPolicy, subject string) (RateLimitResult, error)
+	// Check reports whether Allow would let a request of subject through,
+	// without taking it from the bucket.
+	Check(ctx context.Context, policy config.RateLimitPolicy, subject string) (RateLimitResult, error)
+}
+
+type rateLimiter struct {
+	cache config.Cache
+}
+
+func NewRateLimiter(cache config.Cache) RateLimiter {
+	return &rateLimiter{
+		cache: cache,
+	}
+}
+
+func (l *rateLimiter) Allow(ctx context.Context, policy config.RateLimitPolicy, subject string) (RateLimitResult, error) {
+	return l.take(ctx, policy, subject, 1)
+}
+
+func (l *rateLimiter) Check(ctx context.Context, policy config.RateLimitPolicy, subject string) (RateLimitResult, error) {
+	return l.take(ctx, policy, subject, 0)
+}
+
+func (l *rateLimiter) take(ctx context.Context, policy config.RateLimitPolicy, subject string, cost int) (RateLimitResult, error) {
+	key := "ratelimit:" + policy.Name + ":" + subject
+	res, err := l.cache.Eval(ctx, tokenBucketScript, []string{key}, policy.Limit, policy.Window.Milliseconds(), cost)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("rate limit: unexpected script result %v", res)
+	}
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		if ints[i], ok = v.(int64); !ok {
+			return RateLimitResult{}, fmt.Errorf("rate limit: unexpected script result %v", res)
+		}
+	}
+
+	return RateLimitResult{
+		Allowed:    ints[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+		Reset:      time.Duration(ints[3]) * time.Millisecond,
+	}, nil
+}
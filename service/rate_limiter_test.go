@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"test-backend-altech/config"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedis starts a miniredis, whose clock only moves when the test sets
+// it, and returns it together with a cache connected to it.
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, config.Cache) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	server.SetTime(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC))
+
+	t.Setenv("REDIS_PASSWORD", "")
+	cache, err := config.NewRedisCache(&config.RedisConfig{Host: server.Addr()})
+	if err != nil {
+		t.Fatalf("connect to miniredis: %v", err)
+	}
+	return server, cache
+}
+
+// rateLimitStep advances the clock of Redis by after, then calls Check when
+// check is set and Allow otherwise.
+type rateLimitStep struct {
+	after time.Duration
+	check bool
+	want  RateLimitResult
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	policy := config.RateLimitPolicy{Name: "test", Limit: 3, Window: 3 * time.Second}
+	result := func(allowed bool, remaining int, retryAfter, reset time.Duration) RateLimitResult {
+		return RateLimitResult{Allowed: allowed, Limit: policy.Limit, Remaining: remaining, RetryAfter: retryAfter, Reset: reset}
+	}
+
+	tests := []struct {
+		name  string
+		steps []rateLimitStep
+	}{
+		{
+			name: "allow takes a token each until the bucket is empty",
+			steps: []rateLimitStep{
+				{want: result(true, 2, 0, time.Second)},
+				{want: result(true, 1, 0, 2*time.Second)},
+				{want: result(true, 0, 0, 3*time.Second)},
+				{want: result(false, 0, time.Second, 3*time.Second)},
+			},
+		},
+		{
+			name: "check does not take a token",
+			steps: []rateLimitStep{
+				{check: true, want: result(true, 3, 0, 0)},
+				{check: true, want: result(true, 3, 0, 0)},
+				{want: result(true, 2, 0, time.Second)},
+				{check: true, want: result(true, 2, 0, time.Second)},
+			},
+		},
+		{
+			name: "check on an empty bucket reports when to retry",
+			steps: []rateLimitStep{
+				{want: result(true, 2, 0, time.Second)},
+				{want: result(true, 1, 0, 2*time.Second)},
+				{want: result(true, 0, 0, 3*time.Second)},
+				{after: 250 * time.Millisecond, check: true, want: result(false, 0, 750*time.Millisecond, 2750*time.Millisecond)},
+				{check: true, want: result(false, 0, 750*time.Millisecond, 2750*time.Millisecond)},
+			},
+		},
+		{
+			name: "tokens refill over the window",
+			steps: []rateLimitStep{
+				{want: result(true, 2, 0, time.Second)},
+				{want: result(true, 1, 0, 2*time.Second)},
+				{want: result(true, 0, 0, 3*time.Second)},
+				{after: 1500 * time.Millisecond, want: result(true, 0, 0, 2500*time.Millisecond)},
+				{want: result(false, 0, 500*time.Millisecond, 2500*time.Millisecond)},
+				{after: 500 * time.Millisecond, want: result(true, 0, 0, 3*time.Second)},
+			},
+		},
+		{
+			name: "refill stops at the limit",
+			steps: []rateLimitStep{
+				{want: result(true, 2, 0, time.Second)},
+				{after: time.Hour, check: true, want: result(true, 3, 0, 0)},
+				{want: result(true, 2, 0, time.Second)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, cache := newTestRedis(t)
+			limiter := NewRateLimiter(cache)
+			now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+			for i, step := range tt.steps {
+				now = now.Add(step.after)
+				server.SetTime(now)
+
+				var got RateLimitResult
+				var err error
+				if step.check {
+					got, err = limiter.Check(context.Background(), policy, "user:1")
+				} else {
+					got, err = limiter.Allow(context.Background(), policy, "user:1")
+				}
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if got != step.want {
+					t.Errorf("step %d: got %+v, want %+v", i, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterKeepsSubjectsApart(t *testing.T) {
+	_, cache := newTestRedis(t)
+	limiter := NewRateLimiter(cache)
+	policy := config.RateLimitPolicy{Name: "test", Limit: 1, Window: time.Minute}
+
+	if got, err := limiter.Allow(context.Background(), policy, "user:1"); err != nil || !got.Allowed {
+		t.Fatalf("first request of user:1: %+v, %v", got, err)
+	}
+	if got, err := limiter.Allow(context.Background(), policy, "user:1"); err != nil || got.Allowed {
+		t.Fatalf("second request of user:1: %+v, %v", got, err)
+	}
+	if got, err := limiter.Allow(context.Background(), policy, "user:2"); err != nil || !got.Allowed {
+		t.Fatalf("first request of user:2: %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+)
+
+type RoleService interface {
+	FindAllRoles(ctx context.Context) ([]response.RoleResponse, error)
+	CreateRole(ctx context.Context, request request.RoleRequest) (response.RoleResponse, error)
+	SetRolePermissions(ctx context.Context, name string, request request.RolePermissionsRequest) (response.RoleResponse, error)
+	DeleteRole(ctx context.Context, name string) error
+	FindAllPermissions(ctx context.Context) ([]response.PermissionResponse, error)
+	FindUserRoles(ctx context.Context, userId string) ([]string, error)
+	SetUserRoles(ctx context.Context, userId string, request request.UserRolesRequest) ([]string, error)
+	FindPermissions(ctx context.Context, userId string) ([]string, error)
+}
+
+type roleService struct {
+	roleRepository repository.RoleRepository
+	userRepository repository.UserRepository
+	uow            repository.UnitOfWork
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, userRepository repository.UserRepository, uow repository.UnitOfWork) RoleService {
+	return &roleService{
+		roleRepository: roleRepository,
+		userRepository: userRepository,
+		uow:            uow,
+	}
+}
+
+func (s *roleService) FindAllRoles(ctx context.Context) ([]response.RoleResponse, error) {
+	res, err := s.roleRepository.FindAllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []response.RoleResponse{}
+	for _, v := range res {
+		data = append(data, v.ToRoleResponse())
+	}
+	return data, nil
+}
+
+func (s *roleService) CreateRole(ctx context.Context, request request.RoleRequest) (response.RoleResponse, error) {
+	var data domain.Role
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		role := domain.Role{Name: request.Name, Description: request.Description}
+		if err := s.roleRepository.CreateRole(ctx, role); err != nil {
+			return err
+		}
+		if err := s.roleRepository.SetRolePermissions(ctx, role.Name, request.Permissions); err != nil {
+			return err
+		}
+
+		var err error
+		data, err = s.roleRepository.FindRole(ctx, role.Name)
+		return err
+	})
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+	return data.ToRoleResponse(), nil
+}
+
+func (s *roleService) SetRolePermissions(ctx context.Context, name string, request request.RolePermissionsRequest) (response.RoleResponse, error) {
+	var data domain.Role
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.roleRepository.FindRole(ctx, name); err != nil {
+			return err
+		}
+		if err := s.roleRepository.SetRolePermissions(ctx, name, request.Permissions); err != nil {
+			return err
+		}
+
+		var err error
+		data, err = s.roleRepository.FindRole(ctx, name)
+		return err
+	})
+	if err != nil {
+		return response.RoleResponse{}, err
+	}
+	return data.ToRoleResponse(), nil
+}
+
+// DeleteRole removes a role and takes it away from every user. Built-in roles
+// can only be emptied, not deleted.
+func (s *roleService) DeleteRole(ctx context.Context, name string) error {
+	if slices.Contains(domain.BuiltinRoles, name) {
+		return &domain.InvalidInputError{Field: "name", Reason: "is a built-in role"}
+	}
+	return s.roleRepository.DeleteRole(ctx, name)
+}
+
+func (s *roleService) FindAllPermissions(ctx context.Context) ([]response.PermissionResponse, error) {
+	res, err := s.roleRepository.FindAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []response.PermissionResponse{}
+	for _, v := range res {
+		data = append(data, v.ToPermissionResponse())
+	}
+	return data, nil
+}
+
+func (s *roleService) FindUserRoles(ctx context.Context, userId string) ([]string, error) {
+	var roles []string
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepository.FindByID(ctx, userId); err != nil {
+			return err
+		}
+
+		var err error
+		roles, err = s.roleRepository.FindUserRoles(ctx, userId)
+		return err
+	})
+	return roles, err
+}
+
+func (s *roleService) SetUserRoles(ctx context.Context, userId string, request request.UserRolesRequest) ([]string, error) {
+	var roles []string
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		if _, err := s.userRepository.FindByID(ctx, userId); err != nil {
+			return err
+		}
+		if err := s.roleRepository.SetUserRoles(ctx, userId, request.Roles); err != nil {
+			return err
+		}
+
+		var err error
+		roles, err = s.roleRepository.FindUserRoles(ctx, userId)
+		return err
+	})
+	return roles, err
+}
+
+// FindPermissions returns every permission the user holds through its roles.
+func (s *roleService) FindPermissions(ctx context.Context, userId string) ([]string, error) {
+	return s.roleRepository.FindUserPermissions(ctx, userId)
+}
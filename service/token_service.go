@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"test-backend-altech/config"
+	"test-backend-altech/model/domain"
+	response "test-backend-altech/model/web/response"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+// tokenClaims are the claims of both token types. Refresh tokens belong to a
+// family that starts at login, so a reused refresh token revokes every
+// token rotated from the same login.
+type tokenClaims struct {
+	Type   string `json:"typ"`
+	Email  string `json:"email,omitempty"`
+	Family string `json:"fam,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, user domain.User, family string) (response.TokenResponse, error)
+	ConsumeRefreshToken(ctx context.Context, token string) (userId string, family string, err error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	ParseAccessToken(token string) (domain.Principal, error)
+}
+
+type tokenService struct {
+	config config.AuthConfig
+	cache  config.Cache
+}
+
+func NewTokenService(config config.AuthConfig, cache config.Cache) TokenService {
+	return &tokenService{
+		config: config,
+		cache:  cache,
+	}
+}
+
+// IssueTokens signs a new access and refresh token for user. An empty family
+// starts a new one, as on login. The refresh token is only valid while its
+// id is stored in Redis.
+func (s *tokenService) IssueTokens(ctx context.Context, user domain.User, family string) (response.TokenResponse, error) {
+	if family == "" {
+		family = uuid.NewString()
+	}
+	now := time.Now()
+
+	accessToken, err := s.sign(tokenClaims{
+		Type:             tokenTypeAccess,
+		Email:            user.Email,
+		RegisteredClaims: s.registeredClaims(user.Id, now, s.config.AccessTTL),
+	})
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	refreshClaims := tokenClaims{
+		Type:             tokenTypeRefresh,
+		Family:           family,
+		RegisteredClaims: s.registeredClaims(user.Id, now, s.config.RefreshTTL),
+	}
+	refreshToken, err := s.sign(refreshClaims)
+	if err != nil {
+		return response.TokenResponse{}, err
+	}
+	if err := s.cache.Set(ctx, refreshTokenKey(refreshClaims.ID), []byte(user.Id), s.config.RefreshTTL); err != nil {
+		return response.TokenResponse{}, err
+	}
+
+	return response.TokenResponse{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.config.AccessTTL.Seconds()),
+		RefreshExpiresIn: int64(s.config.RefreshTTL.Seconds()),
+	}, nil
+}
+
+// ConsumeRefreshToken validates a refresh token and marks it used. A token
+// that was already used means it leaked, so its whole family is revoked.
+func (s *tokenService) ConsumeRefreshToken(ctx context.Context, token string) (string, string, error) {
+	claims, err := s.parse(token, tokenTypeRefresh)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := s.cache.Get(ctx, revokedFamilyKey(claims.Family)); err == nil {
+		return "", "", &domain.UnauthenticatedError{Reason: domain.ReasonTokenRevoked}
+	} else if !errors.Is(err, config.ErrCacheMiss) {
+		return "", "", err
+	}
+
+	if _, err := s.cache.GetDel(ctx, refreshTokenKey(claims.ID)); err != nil {
+		if !errors.Is(err, config.ErrCacheMiss) {
+			return "", "", err
+		}
+		if err := s.revokeFamily(ctx, claims.Family); err != nil {
+			return "", "", err
+		}
+		return "", "", &domain.UnauthenticatedError{Reason: domain.ReasonTokenRevoked}
+	}
+
+	return claims.Subject, claims.Family, nil
+}
+
+// RevokeRefreshToken ends the login a refresh token belongs to.
+func (s *tokenService) RevokeRefreshToken(ctx context.Context, token string) error {
+	claims, err := s.parse(token, tokenTypeRefresh)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.Delete(ctx, refreshTokenKey(claims.ID)); err != nil {
+		return err
+	}
+	return s.revokeFamily(ctx, claims.Family)
+}
+
+func (s *tokenService) ParseAccessToken(token string) (domain.Principal, error) {
+	claims, err := s.parse(token, tokenTypeAccess)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	return domain.Principal{UserId: claims.Subject, Email: claims.Email}, nil
+}
+
+func (s *tokenService) registeredClaims(subject string, now time.Time, ttl time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    s.config.Issuer,
+		Subject:   subject,
+		ID:        uuid.NewString(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+}
+
+func (s *tokenService) sign(claims tokenClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.Secret)
+}
+
+func (s *tokenService) parse(token string, tokenType string) (*tokenClaims, error) {
+	claims := &tokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims,
+		func(*jwt.Token) (interface{}, error) {
+			return s.config.Secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.config.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, &domain.UnauthenticatedError{Reason: domain.ReasonTokenExpired, Err: err}
+	}
+	if err != nil {
+		return nil, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken, Err: err}
+	}
+	if claims.Type != tokenType || claims.Subject == "" {
+		return nil, &domain.UnauthenticatedError{Reason: domain.ReasonInvalidToken}
+	}
+	return claims, nil
+}
+
+func (s *tokenService) revokeFamily(ctx context.Context, family string) error {
+	return s.cache.Set(ctx, revokedFamilyKey(family), []byte("1"), s.config.RefreshTTL)
+}
+
+func refreshTokenKey(id string) string {
+	return "auth:refresh:" + id
+}
+
+func revokedFamilyKey(family string) string {
+	return "auth:family:" + family + ":revoked"
+}
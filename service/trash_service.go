@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+)
+
+type TrashService interface {
+	FindTrash(ctx context.Context) (response.TrashResponse, error)
+	Purge(ctx context.Context, before time.Time) (response.PurgeResponse, error)
+}
+
+type trashService struct {
+	authorRepository repository.AuthorRepository
+	bookRepository   repository.BookRepository
+	uow              repository.UnitOfWork
+}
+
+func NewTrashService(authorRepository repository.AuthorRepository, bookRepository repository.BookRepository, uow repository.UnitOfWork) TrashService {
+	return &trashService{
+		authorRepository: authorRepository,
+		bookRepository:   bookRepository,
+		uow:              uow,
+	}
+}
+
+func (s *trashService) FindTrash(ctx context.Context) (response.TrashResponse, error) {
+	data := response.TrashResponse{
+		Authors: []response.AuthorResponse{},
+		Books:   []response.BookResponse{},
+	}
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		authors, err := s.authorRepository.FindDeletedAuthors(ctx)
+		if err != nil {
+			return err
+		}
+		for _, v := range authors {
+			data.Authors = append(data.Authors, v.ToAuthorResponse())
+		}
+
+		books, err := s.bookRepository.FindDeletedBooks(ctx)
+		if err != nil {
+			return err
+		}
+		data.Books = append(data.Books, books...)
+		return nil
+	})
+	if err != nil {
+		return response.TrashResponse{}, err
+	}
+	return data, nil
+}
+
+// Purge hard-deletes everything moved to the trash before the given time.
+// Books go first so their authors are no longer referenced.
+func (s *trashService) Purge(ctx context.Context, before time.Time) (response.PurgeResponse, error) {
+	var data response.PurgeResponse
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		data.Books, err = s.bookRepository.PurgeBooks(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		data.Authors, err = s.authorRepository.PurgeAuthors(ctx, before)
+		return err
+	})
+	if err != nil {
+		return response.PurgeResponse{}, err
+	}
+	return data, nil
+}
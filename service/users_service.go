@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"test-backend-altech/exception"
+	"test-backend-altech/model/domain"
+	request "test-backend-altech/model/web/req"
+	response "test-backend-altech/model/web/response"
+	"test-backend-altech/repository"
+)
+
+type UserService interface {
+	CreateUser(ctx context.Context, request request.UserRequest) (response.UserResponse, error)
+	FindByID(ctx context.Context, id string) (response.UserResponse, error)
+	EnsureInitialUser(ctx context.Context, email string, password string) error
+}
+
+type userService struct {
+	userRepository repository.UserRepository
+	roleRepository repository.RoleRepository
+	uow            repository.UnitOfWork
+}
+
+func NewUserService(userRepository repository.UserRepository, roleRepository repository.RoleRepository, uow repository.UnitOfWork) UserService {
+	return &userService{
+		userRepository: userRepository,
+		roleRepository: roleRepository,
+		uow:            uow,
+	}
+}
+
+func (s *userService) CreateUser(ctx context.Context, request request.UserRequest) (response.UserResponse, error) {
+	passwordHash, err := hashPassword(request.Password)
+	if err != nil {
+		return response.UserResponse{}, err
+	}
+	user := domain.User{
+		Email:        request.Email,
+		Name:         request.Name,
+		PasswordHash: passwordHash,
+	}
+	user.GenerateID()
+	roles := request.Roles
+	if len(roles) == 0 {
+		roles = []string{domain.RoleReader}
+	}
+
+	var data response.UserResponse
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		if err := s.roleRepository.SetUserRoles(ctx, user.Id, roles); err != nil {
+			return err
+		}
+
+		var err error
+		data, err = s.FindByID(ctx, user.Id)
+		if err != nil {
+			return exception.ErrInternalServer(fmt.Sprintf("Successfully created user, but failed to get the created user. Error: %s", err.Error()))
+		}
+		return nil
+	})
+	if err != nil {
+		return response.UserResponse{}, err
+	}
+	return data, nil
+}
+
+func (s *userService) FindByID(ctx context.Context, id string) (response.UserResponse, error) {
+	res, err := s.userRepository.FindByID(ctx, id)
+	if err != nil {
+		return response.UserResponse{}, err
+	}
+	roles, err := s.roleRepository.FindUserRoles(ctx, id)
+	if err != nil {
+		return response.UserResponse{}, err
+	}
+
+	data := res.ToUserResponse()
+	data.Roles = roles
+	return data, nil
+}
+
+// EnsureInitialUser creates the first user from the configuration as an admin
+// when there are no users yet, so a fresh installation can log in at all.
+func (s *userService) EnsureInitialUser(ctx context.Context, email string, password string) error {
+	if email == "" || password == "" {
+		return nil
+	}
+
+	count, err := s.userRepository.CountUsers(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = s.CreateUser(ctx, request.UserRequest{Email: email, Name: "Administrator", Password: password, Roles: []string{domain.RoleAdmin}})
+	return err
+}